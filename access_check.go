@@ -0,0 +1,97 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// AccessViolation a component accessed against the requirements of the systems, found by
+// WorldConfig.AccessCheck
+type AccessViolation struct {
+	Frame      uint64
+	Component  string
+	Systems    []string // the systems suspected, all the systems of the batch when Undeclared
+	Undeclared bool     // the component is not in the requirements of the systems
+}
+
+func (v AccessViolation) String() string {
+	if v.Undeclared {
+		return fmt.Sprintf("access violation in frame %d: %s accessed without requirement by %s",
+			v.Frame, v.Component, strings.Join(v.Systems, ", "))
+	}
+	return fmt.Sprintf("access violation in frame %d: read only %s modified by %s",
+		v.Frame, v.Component, strings.Join(v.Systems, ", "))
+}
+
+// accessCheck checksums of the component sets before a batch, and the systems run in the batch
+type accessCheck struct {
+	checksums map[reflect.Type]uint64
+	systems   []ISystem
+}
+
+func (w *ecsWorld) reportAccessViolation(v AccessViolation) {
+	if w.config.OnAccessViolation != nil {
+		w.config.OnAccessViolation(v)
+		return
+	}
+	w.logger.Log(LogLevelError, v.String())
+}
+
+// reportUndeclared a system getting a component it does not require
+func reportUndeclared(sys ISystem, typ reflect.Type) {
+	w := sys.World().base()
+	if !w.config.AccessCheck {
+		return
+	}
+	w.reportAccessViolation(AccessViolation{
+		Frame:      w.frame,
+		Component:  typ.String(),
+		Systems:    []string{sys.Type().String()},
+		Undeclared: true,
+	})
+}
+
+func (p *systemFlow) beginAccessCheck() *accessCheck {
+	check := &accessCheck{checksums: map[reflect.Type]uint64{}}
+	p.world.components.getCollections().Range(func(set *IComponentSet) bool {
+		check.checksums[(*set).GetElementMeta().typ] = (*set).checksum()
+		return true
+	})
+	return check
+}
+
+// endAccessCheck compare the component sets with the checksums, a modified set must be required
+// with write permission by a system of the batch
+func (p *systemFlow) endAccessCheck(check *accessCheck, frame uint64) {
+	if len(check.systems) == 0 {
+		return
+	}
+	p.world.components.getCollections().Range(func(set *IComponentSet) bool {
+		typ := (*set).GetElementMeta().typ
+		sum, ok := check.checksums[typ]
+		if !ok || sum == (*set).checksum() {
+			return true
+		}
+		var readers []string
+		for _, sys := range check.systems {
+			r, ok := sys.GetRequirements()[typ]
+			if !ok {
+				continue
+			}
+			if r.getPermission() != ComponentReadOnly {
+				return true
+			}
+			readers = append(readers, sys.Type().String())
+		}
+		v := AccessViolation{Frame: frame, Component: typ.String(), Systems: readers}
+		if len(readers) == 0 {
+			v.Undeclared = true
+			for _, sys := range check.systems {
+				v.Systems = append(v.Systems, sys.Type().String())
+			}
+		}
+		p.world.reportAccessViolation(v)
+		return true
+	})
+}
@@ -0,0 +1,111 @@
+package ecs
+
+import (
+	"sync"
+	"testing"
+)
+
+type __accessCheck_Test_C_1 struct {
+	Component[__accessCheck_Test_C_1]
+	Field1 int
+}
+
+type __accessCheck_Test_C_2 struct {
+	Component[__accessCheck_Test_C_2]
+	Field1 int
+}
+
+type __accessCheck_Test_Shape_1 struct {
+	c1 *__accessCheck_Test_C_1
+}
+
+// __accessCheck_Test_S_1 writes through a read only shape
+type __accessCheck_Test_S_1 struct {
+	System[__accessCheck_Test_S_1]
+
+	shape *Shape[__accessCheck_Test_Shape_1]
+}
+
+func (s *__accessCheck_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__accessCheck_Test_C_1]{})
+	s.shape = NewShape[__accessCheck_Test_Shape_1](si)
+	return nil
+}
+
+func (s *__accessCheck_Test_S_1) Update(event Event) {
+	iter := s.shape.Get()
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		v.c1.Field1++
+	}
+}
+
+// __accessCheck_Test_S_2 writes through a read only getter, and gets a component it does not require
+type __accessCheck_Test_S_2 struct {
+	System[__accessCheck_Test_S_2]
+
+	entity Entity
+}
+
+func (s *__accessCheck_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__accessCheck_Test_C_1]{})
+	return nil
+}
+
+func (s *__accessCheck_Test_S_2) Update(event Event) {
+	if c := GetRelated[__accessCheck_Test_C_1](s, s.entity); c != nil {
+		c.Field1 += 100
+	}
+	GetRelated[__accessCheck_Test_C_2](s, s.entity)
+}
+
+func TestAccessCheck(t *testing.T) {
+	lock := &sync.Mutex{}
+	var violations []AccessViolation
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.AccessCheck = true
+	// both systems write C_1 in the same batch, a single worker runs them one after another so the
+	// violations are detected without a data race
+	config.MaxPoolThread = 1
+	config.OnAccessViolation = func(v AccessViolation) {
+		lock.Lock()
+		violations = append(violations, v)
+		lock.Unlock()
+	}
+	world := NewSyncWorld(config)
+	RegisterSystem[__accessCheck_Test_S_1](world)
+	RegisterSystem[__accessCheck_Test_S_2](world)
+	world.registerComponent(&__accessCheck_Test_C_2{})
+	world.Startup()
+
+	e := world.NewEntity()
+	world.Add(e, &__accessCheck_Test_C_1{}, &__accessCheck_Test_C_2{})
+	s, _ := world.getSystem(TypeOf[__accessCheck_Test_S_2]())
+	s.(*__accessCheck_Test_S_2).entity = e
+	world.Update()
+	world.Update()
+
+	c := world.getComponentSet(TypeOf[__accessCheck_Test_C_1]()).(*ComponentSet[__accessCheck_Test_C_1]).getByEntity(e)
+	if c.Field1 >= 100 {
+		t.Fatal("read only getter returned the shared data")
+	}
+
+	var modified, undeclared int
+	for _, v := range violations {
+		if v.Undeclared {
+			if v.Systems[0] != TypeOf[__accessCheck_Test_S_2]().String() {
+				t.Fatalf("undeclared, got %s", v)
+			}
+			undeclared++
+			continue
+		}
+		// both systems read C_1 in the same batch
+		if v.Component != TypeOf[__accessCheck_Test_C_1]().String() || len(v.Systems) != 2 {
+			t.Fatalf("read only, got %s", v)
+		}
+		modified++
+	}
+	if modified == 0 || undeclared == 0 {
+		t.Fatalf("violations, got %v", violations)
+	}
+}
@@ -0,0 +1,386 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// archetype a table of the entities with the same component signature, the rows are stored in
+// chunks of ChunkSize bytes, every chunk is full except the last one
+type archetype struct {
+	compound Compound
+	types    []reflect.Type
+	sizes    []uintptr
+	capacity int // rows per chunk
+	chunks   []*archetypeChunk
+	len      int
+}
+
+// archetypeChunk columns[i] holds the components compound[i] of the rows, entities the owners
+type archetypeChunk struct {
+	entities []Entity
+	columns  []unsafe.Pointer
+}
+
+// archetypeLocation the row of an entity, indexed by entity index
+type archetypeLocation struct {
+	table *archetype
+	chunk int32
+	row   int32
+}
+
+// archetypeRun the rows of a component type in one chunk
+type archetypeRun struct {
+	start int
+	base  unsafe.Pointer
+	count int
+}
+
+// archetypeColumn column index of a component type in an archetype
+type archetypeColumn struct {
+	table *archetype
+	index int
+}
+
+// archetypeView the component set of a signature component in archetype storage mode, the
+// components are the columns of the archetypes, runs are rebuilt after the rows moved
+type archetypeView struct {
+	storage *archetypeStorage
+	it      uint16
+	size    uintptr
+	tables  []archetypeColumn
+	runs    []archetypeRun
+	len     int
+	dirty   bool
+}
+
+// archetypeStorage groups entities by the signature of their Compound, the signature only
+// contains normal components, free and disposable components are transient and stay in sparse
+// sets. Entities touched by a flush are moved between the tables before the op tasks run.
+type archetypeStorage struct {
+	world     *ecsWorld
+	tables    map[interface{}]*archetype
+	list      []*archetype
+	locs      []archetypeLocation
+	views     map[uint16]*archetypeView
+	dirty     []*archetypeView
+	pending   map[Entity]*archetype // table of the touched entities before the flush
+	order     []Entity
+	signature Compound
+	version   uint64 // bumped when a table is created
+}
+
+func newArchetypeStorage(world *ecsWorld) *archetypeStorage {
+	return &archetypeStorage{
+		world:   world,
+		tables:  map[interface{}]*archetype{},
+		views:   map[uint16]*archetypeView{},
+		pending: map[Entity]*archetype{},
+	}
+}
+
+func (s *archetypeStorage) isSignature(it uint16) bool {
+	meta := s.world.componentMeta.GetComponentMetaInfoByIntType(it)
+	return meta != nil && meta.componentType&(ComponentTypeFreeMask|ComponentTypeDisposableMask) == 0
+}
+
+func (s *archetypeStorage) view(it uint16) *archetypeView {
+	v, ok := s.views[it]
+	if !ok {
+		meta := s.world.componentMeta.GetComponentMetaInfoByIntType(it)
+		v = &archetypeView{storage: s, it: it, size: meta.typ.Size()}
+		s.views[it] = v
+	}
+	return v
+}
+
+func (s *archetypeStorage) table(sig Compound) *archetype {
+	key := getCompoundType(sig)
+	if key == nil {
+		key = string(unsafe.Slice((*byte)(unsafe.Pointer(&sig[0])), len(sig)*2))
+	}
+	if a, ok := s.tables[key]; ok {
+		return a
+	}
+	a := &archetype{
+		compound: append(Compound(nil), sig...),
+		types:    make([]reflect.Type, len(sig)),
+		sizes:    make([]uintptr, len(sig)),
+	}
+	row := unsafe.Sizeof(Entity(0))
+	for i, it := range sig {
+		a.types[i] = s.world.componentMeta.GetComponentMetaInfoByIntType(it).typ
+		a.sizes[i] = a.types[i].Size()
+		row += a.sizes[i]
+		v := s.view(it)
+		v.tables = append(v.tables, archetypeColumn{table: a, index: i})
+	}
+	a.capacity = int(ChunkSize / row)
+	if a.capacity < 1 {
+		a.capacity = 1
+	}
+	s.tables[key] = a
+	s.list = append(s.list, a)
+	s.version++
+	return a
+}
+
+// locate the row of an entity, nil for entities without signature components and stale entities
+func (s *archetypeStorage) locate(entity Entity) (*archetype, *archetypeChunk, int) {
+	index := int(entity.ToRealID().index)
+	if index >= len(s.locs) {
+		return nil, nil, 0
+	}
+	loc := &s.locs[index]
+	if loc.table == nil {
+		return nil, nil, 0
+	}
+	chunk := loc.table.chunks[loc.chunk]
+	if chunk.entities[loc.row] != entity {
+		return nil, nil, 0
+	}
+	return loc.table, chunk, int(loc.row)
+}
+
+func (s *archetypeStorage) setLocation(entity Entity, table *archetype, chunk int, row int) {
+	index := int(entity.ToRealID().index)
+	if index >= len(s.locs) {
+		locs := make([]archetypeLocation, index*2+1)
+		copy(locs, s.locs)
+		s.locs = locs
+	}
+	s.locs[index] = archetypeLocation{table: table, chunk: int32(chunk), row: int32(row)}
+}
+
+// touch record the table of an entity before its compound changes, the entity is moved by apply
+func (s *archetypeStorage) touch(entity Entity) {
+	if s == nil {
+		return
+	}
+	if _, ok := s.pending[entity]; ok {
+		return
+	}
+	table, _, _ := s.locate(entity)
+	s.pending[entity] = table
+	s.order = append(s.order, entity)
+}
+
+// apply move the touched entities to the tables of their signatures, it runs serially before the
+// op tasks, the tasks copy the added components into the rows
+func (s *archetypeStorage) apply() {
+	if s == nil || len(s.order) == 0 {
+		return
+	}
+	sort.Slice(s.order, func(i, j int) bool { return s.order[i] < s.order[j] })
+	// destroyed entities first, their index may be reused by a touched entity
+	for _, entity := range s.order {
+		if !s.world.entities.Exist(entity) {
+			s.move(entity, nil)
+		}
+	}
+	for _, entity := range s.order {
+		if info, ok := s.world.getEntityInfo(entity); ok {
+			s.move(entity, s.signatureOf(info.compound))
+		}
+	}
+	s.refresh()
+}
+
+// commit end the flush, the presence of the components no longer depends on the old tables
+func (s *archetypeStorage) commit() {
+	if s == nil || len(s.order) == 0 {
+		return
+	}
+	for _, entity := range s.order {
+		delete(s.pending, entity)
+	}
+	s.order = s.order[:0]
+}
+
+// place put an entity in the table of its signature immediately, used when loading a world
+func (s *archetypeStorage) place(info *EntityInfo) {
+	if s == nil {
+		return
+	}
+	s.move(info.entity, s.signatureOf(info.compound))
+	s.refresh()
+}
+
+func (s *archetypeStorage) signatureOf(compound Compound) Compound {
+	sig := s.signature[:0]
+	for _, it := range compound {
+		if s.isSignature(it) {
+			sig = append(sig, it)
+		}
+	}
+	s.signature = sig
+	return sig
+}
+
+// move the row of an entity to the table of sig, the shared components are copied and the new
+// ones are zeroed, an empty signature removes the row
+func (s *archetypeStorage) move(entity Entity, sig Compound) {
+	from, fromChunk, fromRow := s.locate(entity)
+	var to *archetype
+	if len(sig) > 0 {
+		to = s.table(sig)
+	}
+	if from == to {
+		return
+	}
+	var fromIndex int
+	if from != nil {
+		fromIndex = int(s.locs[entity.ToRealID().index].chunk)
+	}
+	if to != nil {
+		chunk, row := s.push(to, entity)
+		dst := to.chunks[chunk]
+		for i, it := range to.compound {
+			cell := unsafe.Add(dst.columns[i], uintptr(row)*to.sizes[i])
+			if from != nil {
+				if j := from.compound.Find(it); j >= 0 {
+					copy(unsafe.Slice((*byte)(cell), to.sizes[i]),
+						unsafe.Slice((*byte)(unsafe.Add(fromChunk.columns[j], uintptr(fromRow)*from.sizes[j])), from.sizes[j]))
+				}
+			}
+		}
+		s.markDirty(to)
+	}
+	if from != nil {
+		s.removeRow(from, fromIndex, fromRow)
+		s.markDirty(from)
+	}
+	if to == nil {
+		s.locs[entity.ToRealID().index] = archetypeLocation{}
+	}
+}
+
+// push append a zeroed row to the last chunk of the table
+func (s *archetypeStorage) push(a *archetype, entity Entity) (int, int) {
+	n := len(a.chunks)
+	if n == 0 || len(a.chunks[n-1].entities) == a.capacity {
+		chunk := &archetypeChunk{
+			entities: make([]Entity, 0, a.capacity),
+			columns:  make([]unsafe.Pointer, len(a.types)),
+		}
+		for i, typ := range a.types {
+			chunk.columns[i] = reflect.New(reflect.ArrayOf(a.capacity, typ)).UnsafePointer()
+		}
+		a.chunks = append(a.chunks, chunk)
+		n++
+	}
+	chunk := a.chunks[n-1]
+	row := len(chunk.entities)
+	chunk.entities = append(chunk.entities, entity)
+	for i, size := range a.sizes {
+		cell := unsafe.Slice((*byte)(unsafe.Add(chunk.columns[i], uintptr(row)*size)), size)
+		for j := range cell {
+			cell[j] = 0
+		}
+	}
+	a.len++
+	s.setLocation(entity, a, n-1, row)
+	return n - 1, row
+}
+
+// removeRow fill the row with the last row of the table, the emptied last chunk is released
+func (s *archetypeStorage) removeRow(a *archetype, chunk int, row int) {
+	lastChunk := a.chunks[len(a.chunks)-1]
+	lastRow := len(lastChunk.entities) - 1
+	if dst := a.chunks[chunk]; dst != lastChunk || row != lastRow {
+		for i, size := range a.sizes {
+			copy(unsafe.Slice((*byte)(unsafe.Add(dst.columns[i], uintptr(row)*size)), size),
+				unsafe.Slice((*byte)(unsafe.Add(lastChunk.columns[i], uintptr(lastRow)*size)), size))
+		}
+		moved := lastChunk.entities[lastRow]
+		dst.entities[row] = moved
+		s.setLocation(moved, a, chunk, row)
+	}
+	lastChunk.entities = lastChunk.entities[:lastRow]
+	if lastRow == 0 {
+		a.chunks[len(a.chunks)-1] = nil
+		a.chunks = a.chunks[:len(a.chunks)-1]
+	}
+	a.len--
+}
+
+func (s *archetypeStorage) markDirty(a *archetype) {
+	for _, it := range a.compound {
+		if v := s.views[it]; !v.dirty {
+			v.dirty = true
+			s.dirty = append(s.dirty, v)
+		}
+	}
+}
+
+// refresh rebuild the runs of the views whose tables changed
+func (s *archetypeStorage) refresh() {
+	for _, v := range s.dirty {
+		v.dirty = false
+		v.runs = v.runs[:0]
+		start := 0
+		for _, column := range v.tables {
+			for _, chunk := range column.table.chunks {
+				v.runs = append(v.runs, archetypeRun{start: start, base: chunk.columns[column.index], count: len(chunk.entities)})
+				start += len(chunk.entities)
+			}
+		}
+		v.len = start
+	}
+	s.dirty = s.dirty[:0]
+}
+
+// get the component of an entity, the owner is not checked
+func (v *archetypeView) get(entity Entity) unsafe.Pointer {
+	table, chunk, row := v.storage.locate(entity)
+	if table == nil {
+		return nil
+	}
+	index := table.compound.Find(v.it)
+	if index < 0 {
+		return nil
+	}
+	return unsafe.Add(chunk.columns[index], uintptr(row)*v.size)
+}
+
+func (v *archetypeView) getByIndex(index int) unsafe.Pointer {
+	i := sort.Search(len(v.runs), func(i int) bool {
+		return v.runs[i].start+v.runs[i].count > index
+	})
+	if i == len(v.runs) {
+		return nil
+	}
+	return unsafe.Add(v.runs[i].base, uintptr(index-v.runs[i].start)*v.size)
+}
+
+// archetypePresence the presence of a signature component while the op tasks of a flush run,
+// the rows have already been moved, the presence follows the ops of the task list starting from
+// the table the entity had before the flush
+type archetypePresence struct {
+	storage *archetypeStorage
+	it      uint16
+	state   map[Entity]bool
+}
+
+func newArchetypePresence(storage *archetypeStorage, it uint16) *archetypePresence {
+	if storage == nil || !storage.isSignature(it) {
+		return nil
+	}
+	return &archetypePresence{storage: storage, it: it, state: map[Entity]bool{}}
+}
+
+func (p *archetypePresence) exist(entity Entity) bool {
+	if v, ok := p.state[entity]; ok {
+		return v
+	}
+	if table, ok := p.storage.pending[entity]; ok {
+		return table != nil && table.compound.Exist(p.it)
+	}
+	table, _, _ := p.storage.locate(entity)
+	return table != nil && table.compound.Exist(p.it)
+}
+
+func (p *archetypePresence) set(entity Entity, exist bool) {
+	p.state[entity] = exist
+}
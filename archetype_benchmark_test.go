@@ -0,0 +1,118 @@
+package ecs
+
+import (
+	"testing"
+)
+
+const testArchetypeBenchEntities = 100000
+
+type __archetype_Bench_C_1 struct {
+	Component[__archetype_Bench_C_1]
+	X, Y, Z float64
+}
+
+type __archetype_Bench_C_2 struct {
+	Component[__archetype_Bench_C_2]
+	X, Y, Z float64
+}
+
+type __archetype_Bench_C_3 struct {
+	Component[__archetype_Bench_C_3]
+	Value int
+}
+
+type __archetype_Bench_C_4 struct {
+	Component[__archetype_Bench_C_4]
+	Value int
+}
+
+type __archetype_Bench_Shape_1 struct {
+	c1 *__archetype_Bench_C_1
+	c2 *__archetype_Bench_C_2
+}
+
+type __archetype_Bench_S_1 struct {
+	System[__archetype_Bench_S_1]
+	shape *Shape[__archetype_Bench_Shape_1]
+}
+
+func (s *__archetype_Bench_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__archetype_Bench_C_1{}, &__archetype_Bench_C_2{}, &__archetype_Bench_C_3{}, &__archetype_Bench_C_4{})
+	s.shape = NewShape[__archetype_Bench_Shape_1](si)
+	return nil
+}
+
+func (s *__archetype_Bench_S_1) Update(event Event) {
+	iter := s.shape.Get()
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		v.c1.X += v.c2.X
+		v.c1.Y += v.c2.Y
+		v.c1.Z += v.c2.Z
+	}
+}
+
+// newArchetypeBenchWorld entities over 4 archetypes, all of them matched by the shape
+func newArchetypeBenchWorld(archetype bool) (*SyncWorld, []Entity) {
+	config := NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.ArchetypeStorage = archetype
+	world := NewSyncWorld(config)
+	RegisterSystem[__archetype_Bench_S_1](world)
+	world.Startup()
+	entities := make([]Entity, 0, testArchetypeBenchEntities)
+	for i := 0; i < testArchetypeBenchEntities; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__archetype_Bench_C_1{X: 1}, &__archetype_Bench_C_2{X: 1, Y: 2, Z: 3})
+		if i%2 == 0 {
+			world.Add(e, &__archetype_Bench_C_3{})
+		}
+		if i%3 == 0 {
+			world.Add(e, &__archetype_Bench_C_4{})
+		}
+		entities = append(entities, e)
+	}
+	world.Update()
+	return world, entities
+}
+
+func benchmarkArchetypeShapeIter(b *testing.B, archetype bool) {
+	world, _ := newArchetypeBenchWorld(archetype)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		world.Update()
+	}
+}
+
+func BenchmarkArchetype_ShapeIter_Sparse(b *testing.B) {
+	benchmarkArchetypeShapeIter(b, false)
+}
+
+func BenchmarkArchetype_ShapeIter_Archetype(b *testing.B) {
+	benchmarkArchetypeShapeIter(b, true)
+}
+
+// benchmarkArchetypeStructuralChange 100 entities change their archetype every frame
+func benchmarkArchetypeStructuralChange(b *testing.B, archetype bool) {
+	world, entities := newArchetypeBenchWorld(archetype)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			e := entities[(i*100+j)%len(entities)]
+			if i%2 == 0 {
+				world.Remove(e, &__archetype_Bench_C_3{})
+			} else {
+				world.Add(e, &__archetype_Bench_C_3{})
+			}
+		}
+		world.Update()
+	}
+}
+
+func BenchmarkArchetype_StructuralChange_Sparse(b *testing.B) {
+	benchmarkArchetypeStructuralChange(b, false)
+}
+
+func BenchmarkArchetype_StructuralChange_Archetype(b *testing.B) {
+	benchmarkArchetypeStructuralChange(b, true)
+}
@@ -0,0 +1,278 @@
+package ecs
+
+import "unsafe"
+
+const (
+	shapeTableMissing = -1 // optional field not in the archetype
+	shapeTableSparse  = -2 // field not in the signature, looked up by entity
+)
+
+// shapeTable an archetype matched by a shape, columns[i] is the column of field i in the archetype
+type shapeTable struct {
+	table   *archetype
+	columns []int
+	sparse  bool
+}
+
+// matchShapeTables match the archetypes against the fields of a shape, false if the shape has no
+// required field in the signature and can not be iterated by archetype
+func matchShapeTables(storage *archetypeStorage, indices ShapeIndices, buf []shapeTable) ([]shapeTable, bool) {
+	main := false
+	for i, it := range indices.subTypes {
+		if !indices.optional[i] && storage.isSignature(it) {
+			main = true
+			break
+		}
+	}
+	if !main {
+		return buf, false
+	}
+	sparseExclude := false
+	for _, it := range indices.exclude {
+		if !storage.isSignature(it) {
+			sparseExclude = true
+		}
+	}
+
+next:
+	for _, a := range storage.list {
+		for _, it := range indices.exclude {
+			if a.compound.Exist(it) {
+				continue next
+			}
+		}
+		table := shapeTable{table: a, sparse: sparseExclude}
+		for i, it := range indices.subTypes {
+			if !storage.isSignature(it) {
+				table.columns = append(table.columns, shapeTableSparse)
+				table.sparse = true
+				continue
+			}
+			pos := a.compound.Find(it)
+			if pos < 0 {
+				if !indices.optional[i] {
+					continue next
+				}
+				table.columns = append(table.columns, shapeTableMissing)
+				continue
+			}
+			table.columns = append(table.columns, pos)
+		}
+		buf = append(buf, table)
+	}
+	return buf, true
+}
+
+// archetypeShapeIter walks the chunks of the matched archetypes, rows [begin, end) of the
+// concatenated tables
+type archetypeShapeIter[T any] struct {
+	indices ShapeIndices
+	tables  []shapeTable
+	begin   int
+	end     int
+	pos     int
+	table   int
+	chunk   int
+	row     int
+	cur     *T
+}
+
+func newArchetypeShapeIter[T any](indices ShapeIndices, tables []shapeTable) IShapeIterator[T] {
+	iter := &archetypeShapeIter[T]{
+		indices: indices,
+		tables:  tables,
+	}
+	for i := range tables {
+		iter.end += tables[i].table.len
+	}
+	return iter
+}
+
+// seek the row of begin, the chunks of a table are full except the last one
+func (s *archetypeShapeIter[T]) seek() {
+	s.pos, s.table, s.chunk, s.row = s.begin, 0, 0, 0
+	rest := s.begin
+	for ; s.table < len(s.tables); s.table++ {
+		a := s.tables[s.table].table
+		if rest < a.len {
+			s.chunk, s.row = rest/a.capacity, rest%a.capacity
+			return
+		}
+		rest -= a.len
+	}
+}
+
+func (s *archetypeShapeIter[T]) fill(table *shapeTable, chunk *archetypeChunk) bool {
+	entity := chunk.entities[s.row]
+	if table.sparse && s.indices.entitySet != nil {
+		info, ok := s.indices.entitySet.GetEntityInfo(entity)
+		if !ok || info.compound.ExistAny(s.indices.exclude) {
+			return false
+		}
+	}
+	for i, column := range table.columns {
+		var p unsafe.Pointer
+		switch column {
+		case shapeTableMissing:
+		case shapeTableSparse:
+			if s.indices.containers[i] != nil {
+				p = s.indices.containers[i].getPointerByEntity(entity)
+			}
+			if p == nil && !s.indices.optional[i] {
+				return false
+			}
+		default:
+			p = unsafe.Add(chunk.columns[column], uintptr(s.row)*table.table.sizes[column])
+		}
+		*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[i])) = (*byte)(p)
+	}
+	return true
+}
+
+func (s *archetypeShapeIter[T]) tryNext() *T {
+	for s.pos < s.end && s.table < len(s.tables) {
+		table := &s.tables[s.table]
+		if s.chunk >= len(table.table.chunks) {
+			s.table, s.chunk, s.row = s.table+1, 0, 0
+			continue
+		}
+		chunk := table.table.chunks[s.chunk]
+		if s.row >= len(chunk.entities) {
+			s.chunk, s.row = s.chunk+1, 0
+			continue
+		}
+		if s.fill(table, chunk) {
+			return s.cur
+		}
+		s.row++
+		s.pos++
+	}
+	s.cur = nil
+	return nil
+}
+
+func (s *archetypeShapeIter[T]) Begin() *T {
+	s.seek()
+	s.cur = new(T)
+	return s.tryNext()
+}
+
+func (s *archetypeShapeIter[T]) Val() *T {
+	return s.cur
+}
+
+func (s *archetypeShapeIter[T]) Next() *T {
+	if s.cur == nil {
+		return nil
+	}
+	s.row++
+	s.pos++
+	return s.tryNext()
+}
+
+func (s *archetypeShapeIter[T]) End() bool {
+	return s.cur == nil
+}
+
+func (s *archetypeShapeIter[T]) rangeLen() int {
+	return s.end - s.begin
+}
+
+// subRange the rows [begin, end) of the iterator
+func (s *archetypeShapeIter[T]) subRange(begin int, end int) Iterator[T] {
+	if begin >= end {
+		return EmptyShapeIter[T]()
+	}
+	return &archetypeShapeIter[T]{
+		indices: s.indices,
+		tables:  s.tables,
+		begin:   s.begin + begin,
+		end:     s.begin + end,
+	}
+}
+
+// archetypeColumnIter walks the runs of a component set in archetype storage mode
+type archetypeColumnIter[T any] struct {
+	runs     []archetypeRun
+	size     uintptr
+	run      int
+	offset   int
+	cur      *T
+	curTemp  T
+	readOnly bool
+}
+
+func newArchetypeColumnIter[T any](runs []archetypeRun, size uintptr, readOnly bool) Iterator[T] {
+	iter := &archetypeColumnIter[T]{runs: runs, size: size, readOnly: readOnly}
+	iter.Begin()
+	return iter
+}
+
+func (i *archetypeColumnIter[T]) load() *T {
+	for ; i.run < len(i.runs); i.run, i.offset = i.run+1, 0 {
+		if i.offset < i.runs[i.run].count {
+			p := (*T)(unsafe.Add(i.runs[i.run].base, uintptr(i.offset)*i.size))
+			if i.readOnly {
+				i.curTemp = *p
+				p = &i.curTemp
+			}
+			i.cur = p
+			return p
+		}
+	}
+	i.cur = nil
+	return nil
+}
+
+func (i *archetypeColumnIter[T]) Begin() *T {
+	i.run, i.offset = 0, 0
+	return i.load()
+}
+
+func (i *archetypeColumnIter[T]) Val() *T {
+	return i.cur
+}
+
+func (i *archetypeColumnIter[T]) Next() *T {
+	if i.cur == nil {
+		return nil
+	}
+	i.offset++
+	return i.load()
+}
+
+func (i *archetypeColumnIter[T]) End() bool {
+	return i.cur == nil
+}
+
+func (i *archetypeColumnIter[T]) rangeLen() int {
+	n := 0
+	for _, run := range i.runs {
+		n += run.count
+	}
+	return n
+}
+
+// subRange the elements [begin, end), the runs are cut at the bounds
+func (i *archetypeColumnIter[T]) subRange(begin int, end int) Iterator[T] {
+	var runs []archetypeRun
+	start := 0
+	for _, run := range i.runs {
+		lo, hi := begin-start, end-start
+		start += run.count
+		if hi <= 0 {
+			break
+		}
+		if lo >= run.count {
+			continue
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > run.count {
+			hi = run.count
+		}
+		runs = append(runs, archetypeRun{base: unsafe.Add(run.base, uintptr(lo)*i.size), count: hi - lo})
+	}
+	return newArchetypeColumnIter[T](runs, i.size, i.readOnly)
+}
@@ -0,0 +1,227 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __archetype_Test_C_1 struct {
+	Component[__archetype_Test_C_1]
+	Field1 int
+}
+
+type __archetype_Test_C_2 struct {
+	Component[__archetype_Test_C_2]
+	Field1 int
+}
+
+type __archetype_Test_C_3 struct {
+	Component[__archetype_Test_C_3]
+	Field1 int
+}
+
+type __archetype_Test_D_1 struct {
+	DisposableComponent[__archetype_Test_D_1]
+	Field1 int
+}
+
+type __archetype_Test_Shape_1 struct {
+	c1 *__archetype_Test_C_1
+	c2 *__archetype_Test_C_2 `ecs:"optional"`
+	c3 *__archetype_Test_C_3 `ecs:"without"`
+}
+
+type __archetype_Test_Shape_2 struct {
+	c1 *__archetype_Test_C_1
+	d1 *__archetype_Test_D_1
+}
+
+type __archetype_Test_S_1 struct {
+	System[__archetype_Test_S_1]
+
+	shape1  *Shape[__archetype_Test_Shape_1]
+	shape2  *Shape[__archetype_Test_Shape_2]
+	result1 map[Entity]int
+	result2 map[Entity]int
+}
+
+func (s *__archetype_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__archetype_Test_C_1{}, &__archetype_Test_C_2{}, &__archetype_Test_D_1{})
+	s.shape1 = NewShape[__archetype_Test_Shape_1](si)
+	s.shape2 = NewShape[__archetype_Test_Shape_2](si)
+	return nil
+}
+
+func (s *__archetype_Test_S_1) Update(event Event) {
+	s.result1, s.result2 = map[Entity]int{}, map[Entity]int{}
+	iter1 := s.shape1.Get()
+	for v := iter1.Begin(); !iter1.End(); v = iter1.Next() {
+		s.result1[v.c1.Owner()] = v.c1.Field1
+		if v.c2 != nil {
+			s.result1[v.c1.Owner()] += v.c2.Field1
+		}
+	}
+	iter2 := s.shape2.Get()
+	for v := iter2.Begin(); !iter2.End(); v = iter2.Next() {
+		s.result2[v.c1.Owner()] = v.c1.Field1 + v.d1.Field1
+	}
+}
+
+func TestArchetypeStorage_Shape(t *testing.T) {
+	run := func(archetype bool) []map[Entity]int {
+		config := NewDefaultWorldConfig()
+		config.ArchetypeStorage = archetype
+		world := NewSyncWorld(config)
+		RegisterSystem[__archetype_Test_S_1](world)
+		world.Startup()
+		s, _ := world.getSystem(TypeOf[__archetype_Test_S_1]())
+		sys := s.(*__archetype_Test_S_1)
+
+		var entities []Entity
+		for i := 0; i < 30; i++ {
+			e := world.NewEntity()
+			entities = append(entities, e)
+			world.Add(e, &__archetype_Test_C_1{Field1: i})
+			if i%2 == 0 {
+				world.Add(e, &__archetype_Test_C_2{Field1: 100})
+			}
+			if i%3 == 0 {
+				world.Add(e, &__archetype_Test_C_3{})
+			}
+			if i%5 == 0 {
+				world.Add(e, &__archetype_Test_D_1{Field1: 1000})
+			}
+		}
+		world.Update()
+		var results []map[Entity]int
+		results = append(results, sys.result1, sys.result2)
+
+		// move entities between archetypes
+		for i, e := range entities {
+			switch i % 4 {
+			case 0:
+				world.Remove(e, &__archetype_Test_C_2{})
+			case 1:
+				world.Add(e, &__archetype_Test_C_2{Field1: 200})
+			case 2:
+				world.Remove(e, &__archetype_Test_C_3{})
+			case 3:
+				world.DestroyEntity(e)
+			}
+		}
+		world.Update()
+		results = append(results, sys.result1, sys.result2)
+
+		if archetype && !sys.shape1.tableMatched {
+			t.Fatal("shape not iterated by archetype")
+		}
+		return results
+	}
+
+	want, got := run(false), run(true)
+	for i := range want {
+		if len(want[i]) == 0 && i%2 == 0 {
+			t.Fatalf("step %d: empty result", i)
+		}
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("step %d: got %v, want %v", i, got[i], want[i])
+		}
+		for e, v := range want[i] {
+			if got[i][e] != v {
+				t.Fatalf("step %d: entity %d got %d, want %d", i, e, got[i][e], v)
+			}
+		}
+	}
+}
+
+func TestArchetypeStorage_Move(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.ArchetypeStorage = true
+	world := NewSyncWorld(config)
+	RegisterSystem[__archetype_Test_S_1](world)
+	world.Startup()
+	it2 := GetComponentMeta[__archetype_Test_C_2](world).it
+
+	// enough entities for several chunks per table
+	const count = 3000
+	var entities []Entity
+	want := map[Entity][2]int{}
+	for i := 0; i < count; i++ {
+		e := world.NewEntity()
+		entities = append(entities, e)
+		world.Add(e, &__archetype_Test_C_1{Field1: i})
+		want[e] = [2]int{i, -1}
+	}
+	world.Update()
+
+	check := func(step int) {
+		set1 := world.getComponentSet(TypeOf[__archetype_Test_C_1]()).(*ComponentSet[__archetype_Test_C_1])
+		set2 := world.getComponentSet(TypeOf[__archetype_Test_C_2]()).(*ComponentSet[__archetype_Test_C_2])
+		n2 := 0
+		for e, w := range want {
+			c1, c2 := set1.Get(e), set2.Get(e)
+			if c1 == nil || c1.Field1 != w[0] {
+				t.Fatalf("step %d: entity %d c1 got %+v, want %d", step, e, c1, w[0])
+			}
+			if (c2 == nil) != (w[1] < 0) || (c2 != nil && c2.Field1 != w[1]) {
+				t.Fatalf("step %d: entity %d c2 got %+v, want %d", step, e, c2, w[1])
+			}
+			if info, _ := world.getEntityInfo(e); info.Has(it2) != (w[1] >= 0) {
+				t.Fatalf("step %d: entity %d compound mismatch", step, e)
+			}
+			if w[1] >= 0 {
+				n2++
+			}
+		}
+		if set1.Len() != len(want) || set2.Len() != n2 {
+			t.Fatalf("step %d: set length got %d %d, want %d %d", step, set1.Len(), set2.Len(), len(want), n2)
+		}
+		for _, a := range world.archetypes.list {
+			rows := 0
+			for ci, chunk := range a.chunks {
+				if ci < len(a.chunks)-1 && len(chunk.entities) != a.capacity {
+					t.Fatalf("step %d: chunk %d of %v not full", step, ci, a.compound)
+				}
+				for row, e := range chunk.entities {
+					if table, c, r := world.archetypes.locate(e); table != a || c != chunk || r != row {
+						t.Fatalf("step %d: entity %d location mismatch", step, e)
+					}
+				}
+				rows += len(chunk.entities)
+			}
+			if rows != a.len {
+				t.Fatalf("step %d: table %v length got %d, want %d", step, a.compound, a.len, rows)
+			}
+		}
+	}
+	check(0)
+
+	for step := 1; step <= 6; step++ {
+		for i, e := range entities {
+			if _, ok := want[e]; !ok || (i+step)%step != 0 {
+				continue
+			}
+			w := want[e]
+			switch {
+			case i%7 == step:
+				world.DestroyEntity(e)
+				delete(want, e)
+				continue
+			case w[1] < 0:
+				world.Add(e, &__archetype_Test_C_2{Field1: i * step})
+				w[1] = i * step
+			case i%2 == 0:
+				// removed and added again in one frame
+				world.Remove(e, &__archetype_Test_C_2{})
+				world.addComponent(e, &__archetype_Test_C_2{Field1: i + step})
+				w[1] = i + step
+			default:
+				world.Remove(e, &__archetype_Test_C_2{})
+				w[1] = -1
+			}
+			want[e] = w
+		}
+		world.Update()
+		check(step)
+	}
+}
@@ -0,0 +1,296 @@
+package ecs
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+type changeKind uint8
+
+const (
+	changeKindAdded changeKind = 1 << iota
+	changeKindModified
+	changeKindRemoved
+)
+
+// size of the Component header, excluded from change comparison
+var componentHeaderSize = unsafe.Sizeof(EmptyComponent{})
+
+type changeRecord struct {
+	entity Entity
+	frame  uint64
+	kind   changeKind
+}
+
+// changeLog per component set change records ordered by frame, the frame of a record is the
+// first frame in which systems can see the change. Records of the same entity in the same
+// frame are merged.
+type changeLog struct {
+	records []changeRecord
+	base    int64
+	last    map[Entity]int64
+	readers []ISystem
+}
+
+func (l *changeLog) record(entity Entity, frame uint64, kind changeKind) {
+	if l.last == nil {
+		l.last = map[Entity]int64{}
+	}
+	if last, ok := l.last[entity]; ok {
+		if r := &l.records[last-l.base]; r.frame == frame {
+			r.kind |= kind
+			return
+		}
+	}
+	l.last[entity] = l.base + int64(len(l.records))
+	l.records = append(l.records, changeRecord{entity: entity, frame: frame, kind: kind})
+}
+
+// collect entities whose records in [since, until] match kind, the kinds of all the records of an
+// entity in the window are merged, e.g. a component added and modified in a later frame is still
+// added for a window covering both frames
+func (l *changeLog) collect(kind changeKind, since, until uint64, buf []Entity) []Entity {
+	start := sort.Search(len(l.records), func(i int) bool {
+		return l.records[i].frame >= since
+	})
+	masks := map[Entity]changeKind{}
+	n := len(buf)
+	for i := start; i < len(l.records); i++ {
+		r := l.records[i]
+		if r.frame > until {
+			break
+		}
+		if _, ok := masks[r.entity]; !ok {
+			buf = append(buf, r.entity)
+		}
+		masks[r.entity] |= r.kind
+	}
+	matched := buf[:n]
+	for _, entity := range buf[n:] {
+		if masks[entity]&kind != 0 {
+			matched = append(matched, entity)
+		}
+	}
+	return matched
+}
+
+// prune records no reader will query anymore
+func (l *changeLog) prune() {
+	if len(l.records) == 0 {
+		return
+	}
+	min := ^uint64(0)
+	readers := l.readers[:0]
+	for _, sys := range l.readers {
+		// removed systems stop holding back the records
+		if sys.getState() == SystemStateDestroyed {
+			continue
+		}
+		readers = append(readers, sys)
+		if since := sys.nextChangeSince(); since < min {
+			min = since
+		}
+	}
+	l.readers = readers
+	n := sort.Search(len(l.records), func(i int) bool {
+		return l.records[i].frame >= min
+	})
+	if n == 0 {
+		return
+	}
+	for _, r := range l.records[:n] {
+		if l.last[r.entity]-l.base < int64(n) {
+			delete(l.last, r.entity)
+		}
+	}
+	l.records = append(l.records[:0], l.records[n:]...)
+	l.base += int64(n)
+}
+
+type IFilterRequirement interface {
+	IRequirement
+	getFilter() changeKind
+}
+
+// Changed requires read-only access to T and iterates only components added or modified
+// since the system last ran, see GetChanged
+type Changed[T ComponentObject] struct{}
+
+func (r *Changed[T]) Type() reflect.Type {
+	return TypeOf[T]()
+}
+
+func (r *Changed[T]) getPermission() ComponentPermission {
+	return ComponentReadOnly
+}
+
+func (r *Changed[T]) check(initializer SystemInitConstraint) {
+	checkFilter[T](initializer)
+}
+
+func (r *Changed[T]) getFilter() changeKind {
+	return changeKindAdded | changeKindModified
+}
+
+// Added requires read-only access to T and iterates only components added since the system
+// last ran, see GetAdded
+type Added[T ComponentObject] struct{}
+
+func (r *Added[T]) Type() reflect.Type {
+	return TypeOf[T]()
+}
+
+func (r *Added[T]) getPermission() ComponentPermission {
+	return ComponentReadOnly
+}
+
+func (r *Added[T]) check(initializer SystemInitConstraint) {
+	checkFilter[T](initializer)
+}
+
+func (r *Added[T]) getFilter() changeKind {
+	return changeKindAdded
+}
+
+// Removed reports entities whose T was removed since the system last ran, see GetRemoved
+type Removed[T ComponentObject] struct{}
+
+func (r *Removed[T]) Type() reflect.Type {
+	return TypeOf[T]()
+}
+
+func (r *Removed[T]) getPermission() ComponentPermission {
+	return ComponentReadOnly
+}
+
+func (r *Removed[T]) check(initializer SystemInitConstraint) {
+	checkFilter[T](initializer)
+}
+
+func (r *Removed[T]) getFilter() changeKind {
+	return changeKindRemoved
+}
+
+func checkFilter[T ComponentObject](initializer SystemInitConstraint) {
+	ins := any(new(T)).(IComponent)
+	ins.check(initializer)
+	sys := initializer.getSystem()
+	sys.World().getComponentSet(ins.Type()).trackChanges(sys)
+}
+
+// GetChanged iterate components added or modified since the system last ran
+func GetChanged[T ComponentObject](sys ISystem) Iterator[T] {
+	return getFiltered[T](sys, changeKindAdded|changeKindModified)
+}
+
+// GetAdded iterate components added since the system last ran
+func GetAdded[T ComponentObject](sys ISystem) Iterator[T] {
+	return getFiltered[T](sys, changeKindAdded)
+}
+
+// GetRemoved get entities whose component was removed since the system last ran
+func GetRemoved[T ComponentObject](sys ISystem) []Entity {
+	set, _, ok := getFilterSet[T](sys, changeKindRemoved)
+	if !ok {
+		return nil
+	}
+	since, until := sys.changeWindow()
+	entities := set.getChangeLog().collect(changeKindRemoved, since, until, nil)
+	n := 0
+	for _, entity := range entities {
+		if set.getByEntity(entity) == nil {
+			entities[n] = entity
+			n++
+		}
+	}
+	return entities[:n]
+}
+
+func getFilterSet[T ComponentObject](sys ISystem, kind changeKind) (*ComponentSet[T], IRequirement, bool) {
+	if sys.getState() == SystemStateInvalid || !sys.isExecuting() {
+		return nil, nil, false
+	}
+	typ := TypeOf[T]()
+	r, ok := sys.GetRequirements()[typ]
+	if !ok {
+		return nil, nil, false
+	}
+	seti := sys.World().getComponentSet(typ)
+	if seti == nil || seti.getChangeLog() == nil {
+		return nil, nil, false
+	}
+	return seti.(*ComponentSet[T]), r, true
+}
+
+func getFiltered[T ComponentObject](sys ISystem, kind changeKind) Iterator[T] {
+	set, r, ok := getFilterSet[T](sys, kind)
+	if !ok {
+		return EmptyIter[T]()
+	}
+	since, until := sys.changeWindow()
+	entities := set.getChangeLog().collect(kind, since, until, nil)
+	return newEntityListIter[T](set, entities, r.getPermission() == ComponentReadOnly)
+}
+
+// entityListIter iterate components of a set by an entity list, missing components are skipped
+type entityListIter[T ComponentObject] struct {
+	set      *ComponentSet[T]
+	entities []Entity
+	offset   int
+	cur      *T
+	curTemp  T
+	readOnly bool
+}
+
+func newEntityListIter[T ComponentObject](set *ComponentSet[T], entities []Entity, readOnly bool) Iterator[T] {
+	return &entityListIter[T]{
+		set:      set,
+		entities: entities,
+		readOnly: readOnly,
+	}
+}
+
+func (i *entityListIter[T]) tryNext() *T {
+	i.cur = nil
+	for ; i.offset < len(i.entities); i.offset++ {
+		p := i.set.getByEntity(i.entities[i.offset])
+		if p == nil {
+			continue
+		}
+		if i.readOnly {
+			i.curTemp = *p
+			i.cur = &i.curTemp
+		} else {
+			i.cur = p
+		}
+		break
+	}
+	return i.cur
+}
+
+func (i *entityListIter[T]) Begin() *T {
+	i.offset = 0
+	return i.tryNext()
+}
+
+func (i *entityListIter[T]) Val() *T {
+	return i.cur
+}
+
+func (i *entityListIter[T]) Next() *T {
+	i.offset++
+	return i.tryNext()
+}
+
+func (i *entityListIter[T]) End() bool {
+	return i.cur == nil
+}
+
+func componentDataEqual(a, b unsafe.Pointer, size uintptr) bool {
+	return bytes.Equal(
+		unsafe.Slice((*byte)(unsafe.Add(a, componentHeaderSize)), size-componentHeaderSize),
+		unsafe.Slice((*byte)(unsafe.Add(b, componentHeaderSize)), size-componentHeaderSize),
+	)
+}
@@ -0,0 +1,135 @@
+package ecs
+
+import (
+	"sort"
+	"testing"
+)
+
+type __changeDetection_Test_C_1 struct {
+	Component[__changeDetection_Test_C_1]
+	Field1 int
+}
+
+type __changeDetection_Test_C_2 struct {
+	Component[__changeDetection_Test_C_2]
+	Field1 int
+}
+
+type __changeDetection_Test_Shape_1 struct {
+	c1 *__changeDetection_Test_C_1 `ecs:"changed"`
+	c2 *__changeDetection_Test_C_2
+}
+
+type __changeDetection_Test_S_1 struct {
+	System[__changeDetection_Test_S_1]
+
+	shape   *Shape[__changeDetection_Test_Shape_1]
+	changed []int
+	added   []int
+	removed []Entity
+	shaped  []int
+}
+
+func (s *__changeDetection_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si,
+		&ReadOnly[__changeDetection_Test_C_2]{},
+		&Changed[__changeDetection_Test_C_1]{},
+		&Removed[__changeDetection_Test_C_2]{},
+	)
+	s.shape = NewShape[__changeDetection_Test_Shape_1](si)
+	return nil
+}
+
+func (s *__changeDetection_Test_S_1) Update(event Event) {
+	s.changed, s.added, s.removed, s.shaped = nil, nil, nil, nil
+	iter := GetChanged[__changeDetection_Test_C_1](s)
+	for c := iter.Begin(); !iter.End(); c = iter.Next() {
+		s.changed = append(s.changed, c.Field1)
+	}
+	added := GetAdded[__changeDetection_Test_C_1](s)
+	for c := added.Begin(); !added.End(); c = added.Next() {
+		s.added = append(s.added, c.Field1)
+	}
+	s.removed = GetRemoved[__changeDetection_Test_C_2](s)
+	shapeIter := s.shape.Get()
+	for v := shapeIter.Begin(); !shapeIter.End(); v = shapeIter.Next() {
+		s.shaped = append(s.shaped, v.c1.Field1)
+	}
+}
+
+func TestChangeDetection(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__changeDetection_Test_S_1](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__changeDetection_Test_S_1]())
+	sys := s.(*__changeDetection_Test_S_1)
+
+	check := func(step string, got []int, want ...int) {
+		sort.Ints(got)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", step, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %v, want %v", step, got, want)
+			}
+		}
+	}
+
+	var entities []Entity
+	for i := 0; i < 3; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__changeDetection_Test_C_1{Field1: i}, &__changeDetection_Test_C_2{Field1: i})
+		entities = append(entities, e)
+	}
+	world.Update()
+	check("first changed", sys.changed, 0, 1, 2)
+	check("first added", sys.added, 0, 1, 2)
+	check("first shape", sys.shaped, 0, 1, 2)
+
+	world.Update()
+	check("idle changed", sys.changed)
+	check("idle shape", sys.shaped)
+
+	set := world.getComponentSet(TypeOf[__changeDetection_Test_C_1]()).(*ComponentSet[__changeDetection_Test_C_1])
+	set.Get(entities[1]).Field1 = 10
+	world.Update()
+	check("modify changed", sys.changed, 10)
+	check("modify added", sys.added)
+	check("modify shape", sys.shaped, 10)
+
+	world.Remove(entities[2], &__changeDetection_Test_C_2{})
+	world.Update()
+	check("remove changed", sys.changed)
+	if len(sys.removed) != 1 || sys.removed[0] != entities[2] {
+		t.Fatalf("remove: got %v, want [%d]", sys.removed, entities[2])
+	}
+}
+
+func TestChangeLog_collect(t *testing.T) {
+	l := &changeLog{}
+	e1, e2, e3 := Entity(1), Entity(2), Entity(3)
+	l.record(e1, 5, changeKindAdded)
+	l.record(e2, 5, changeKindModified)
+	l.record(e1, 6, changeKindModified)
+	l.record(e3, 6, changeKindAdded)
+	l.record(e3, 7, changeKindRemoved)
+
+	check := func(step string, got []Entity, want ...Entity) {
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", step, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %v, want %v", step, got, want)
+			}
+		}
+	}
+	// the kinds of all the records in the window count, not only the latest one
+	check("added over frames", l.collect(changeKindAdded, 5, 6, nil), e1, e3)
+	check("modified over frames", l.collect(changeKindModified, 5, 6, nil), e1, e2)
+	check("added in a later window", l.collect(changeKindAdded, 6, 6, nil), e3)
+	check("modified in a later window", l.collect(changeKindModified, 6, 7, nil), e1)
+	check("removed", l.collect(changeKindRemoved, 5, 7, nil), e3)
+	check("added and removed", l.collect(changeKindAdded, 5, 7, nil), e1, e3)
+}
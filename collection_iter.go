@@ -66,3 +66,20 @@ func (i *Iter[T]) Next() *T {
 	}
 	return i.cur
 }
+
+func (i *Iter[T]) rangeLen() int {
+	return i.len
+}
+
+func (i *Iter[T]) subRange(begin int, end int) Iterator[T] {
+	sub := &Iter[T]{
+		data:     i.data[begin:end],
+		len:      end - begin,
+		eleSize:  i.eleSize,
+		readOnly: i.readOnly,
+	}
+	if sub.len != 0 {
+		sub.head = unsafe.Pointer(&sub.data[0])
+	}
+	return sub
+}
@@ -0,0 +1,115 @@
+package ecs
+
+import "sync"
+
+type commandOperate uint8
+
+const (
+	commandOperateNewEntity commandOperate = iota
+	commandOperateDestroy
+	commandOperateAdd
+	commandOperateRemove
+)
+
+type command struct {
+	op         commandOperate
+	entity     Entity
+	components []IComponent
+}
+
+// CommandBuffer records structural changes inside any stage of a system, the commands are played
+// back on the main thread at the next flush, buffers of different systems in registration order.
+// Recording is goroutine safe, e.g. from the chunks of ParallelForEach, the commands recorded by
+// different goroutines are played back in the order they were recorded.
+// Entities created by the buffer are provisional (negative) until the playback, they are valid as
+// arguments of the same buffer, and can be resolved to the real entities with Resolve after it
+type CommandBuffer struct {
+	lock        sync.Mutex
+	commands    []command
+	provisional int64
+	created     map[Entity]Entity // provisional entities of the last playback
+}
+
+// NewEntity create a provisional entity with components
+func (c *CommandBuffer) NewEntity(components ...IComponent) Entity {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.provisional++
+	entity := Entity(-c.provisional)
+	c.commands = append(c.commands, command{op: commandOperateNewEntity, entity: entity})
+	if len(components) > 0 {
+		c.commands = append(c.commands, command{op: commandOperateAdd, entity: entity, components: components})
+	}
+	return entity
+}
+
+func (c *CommandBuffer) DestroyEntity(entity Entity) {
+	c.record(command{op: commandOperateDestroy, entity: entity})
+}
+
+func (c *CommandBuffer) Add(entity Entity, components ...IComponent) {
+	c.record(command{op: commandOperateAdd, entity: entity, components: components})
+}
+
+func (c *CommandBuffer) Remove(entity Entity, components ...IComponent) {
+	c.record(command{op: commandOperateRemove, entity: entity, components: components})
+}
+
+func (c *CommandBuffer) record(cmd command) {
+	c.lock.Lock()
+	c.commands = append(c.commands, cmd)
+	c.lock.Unlock()
+}
+
+func (c *CommandBuffer) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.commands)
+}
+
+// Resolve get the real entity of a provisional entity created by the last playback, valid until
+// the next playback of the buffer. Real entities are returned as they are
+func (c *CommandBuffer) Resolve(entity Entity) (Entity, bool) {
+	if entity >= 0 {
+		return entity, true
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	real, ok := c.created[entity]
+	return real, ok
+}
+
+func (c *CommandBuffer) playback(world IWorld) {
+	c.lock.Lock()
+	commands := c.commands
+	c.commands = nil
+	c.lock.Unlock()
+
+	created := make(map[Entity]Entity)
+	for _, cmd := range commands {
+		if cmd.op == commandOperateNewEntity {
+			created[cmd.entity] = world.newEntity().Entity()
+			continue
+		}
+		entity := cmd.entity
+		if entity < 0 {
+			entity = created[entity]
+		}
+		info, ok := world.getEntityInfo(entity)
+		if !ok {
+			continue
+		}
+		switch cmd.op {
+		case commandOperateDestroy:
+			info.Destroy(world)
+		case commandOperateAdd:
+			info.Add(world, cmd.components...)
+		case commandOperateRemove:
+			info.Remove(world, cmd.components...)
+		}
+	}
+
+	c.lock.Lock()
+	c.created = created
+	c.lock.Unlock()
+}
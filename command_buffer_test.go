@@ -0,0 +1,165 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __commandBuffer_Test_C_1 struct {
+	Component[__commandBuffer_Test_C_1]
+	Field1 int
+}
+
+type __commandBuffer_Test_C_2 struct {
+	Component[__commandBuffer_Test_C_2]
+	Field1 int
+}
+
+type __commandBuffer_Test_S_1 struct {
+	System[__commandBuffer_Test_S_1]
+
+	victim      Entity
+	keeper      Entity
+	provisional Entity
+}
+
+func (s *__commandBuffer_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__commandBuffer_Test_C_1{}, &__commandBuffer_Test_C_2{})
+	return nil
+}
+
+func (s *__commandBuffer_Test_S_1) Update(event Event) {
+	if event.Frame != 0 {
+		return
+	}
+	cb := s.Commands()
+	e1 := cb.NewEntity(&__commandBuffer_Test_C_1{Field1: 1})
+	cb.Add(e1, &__commandBuffer_Test_C_2{Field1: 1})
+	s.provisional = e1
+	cb.NewEntity(&__commandBuffer_Test_C_1{Field1: 2})
+	cb.Remove(s.keeper, &__commandBuffer_Test_C_1{})
+	cb.DestroyEntity(s.victim)
+}
+
+type __commandBuffer_Test_S_2 struct {
+	System[__commandBuffer_Test_S_2]
+}
+
+func (s *__commandBuffer_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__commandBuffer_Test_C_2]{})
+	return nil
+}
+
+func (s *__commandBuffer_Test_S_2) PostUpdate(event Event) {
+	if event.Frame != 0 {
+		return
+	}
+	s.Commands().NewEntity(&__commandBuffer_Test_C_2{Field1: 2})
+}
+
+func TestCommandBuffer_playback(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__commandBuffer_Test_S_1](world)
+	RegisterSystem[__commandBuffer_Test_S_2](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__commandBuffer_Test_S_1]())
+	sys := s.(*__commandBuffer_Test_S_1)
+
+	sys.victim = world.NewEntity()
+	world.Add(sys.victim, &__commandBuffer_Test_C_1{Field1: 100})
+	sys.keeper = world.NewEntity()
+	world.Add(sys.keeper, &__commandBuffer_Test_C_1{Field1: 200})
+	world.Update()
+
+	if world.IsAlive(sys.victim) {
+		t.Fatal("destroy command not applied")
+	}
+	set1 := world.getComponentSet(TypeOf[__commandBuffer_Test_C_1]()).(*ComponentSet[__commandBuffer_Test_C_1])
+	if set1.Len() != 2 || !world.IsAlive(sys.keeper) || set1.getByEntity(sys.keeper) != nil {
+		t.Fatalf("components of created entities, got %d", set1.Len())
+	}
+	set2 := world.getComponentSet(TypeOf[__commandBuffer_Test_C_2]()).(*ComponentSet[__commandBuffer_Test_C_2])
+	if set2.Len() != 2 {
+		t.Fatalf("components of created entities, got %d", set2.Len())
+	}
+
+	// played back in registration order, provisional entities resolved to real ones
+	var owner, created Entity
+	for i := 0; i < set1.Len(); i++ {
+		if set1.data[i].Field1 == 1 {
+			owner = set1.data[i].Owner()
+		}
+		if set1.data[i].Owner() > created {
+			created = set1.data[i].Owner()
+		}
+	}
+	if c2 := set2.getByEntity(owner); c2 == nil || c2.Field1 != 1 {
+		t.Fatal("provisional entity not resolved")
+	}
+	if real, ok := sys.Commands().Resolve(sys.provisional); !ok || real != owner {
+		t.Fatalf("resolve provisional entity, want %d got %d", owner, real)
+	}
+	var last Entity
+	for i := 0; i < set2.Len(); i++ {
+		if set2.data[i].Field1 == 2 {
+			last = set2.data[i].Owner()
+		}
+	}
+	if last.ToRealID().index <= created.ToRealID().index {
+		t.Fatal("command buffers not played back in registration order")
+	}
+	if sys.Commands().Len() != 0 {
+		t.Fatal("command buffer not reset")
+	}
+}
+
+type __commandBuffer_Test_S_3 struct {
+	System[__commandBuffer_Test_S_3]
+}
+
+func (s *__commandBuffer_Test_S_3) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__commandBuffer_Test_C_1{}, &__commandBuffer_Test_C_2{})
+	return nil
+}
+
+func (s *__commandBuffer_Test_S_3) Update(event Event) {
+	if event.Frame != 0 {
+		return
+	}
+	cb := s.Commands()
+	ParallelForEach(s, GetComponentAll[__commandBuffer_Test_C_1](s), func(c *__commandBuffer_Test_C_1) {
+		e := cb.NewEntity(&__commandBuffer_Test_C_2{Field1: c.Field1})
+		cb.Add(e, &__commandBuffer_Test_C_1{Field1: -c.Field1})
+	})
+}
+
+func TestCommandBuffer_parallel(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__commandBuffer_Test_S_3](world)
+	world.Startup()
+
+	const count = 10000
+	for i := 0; i < count; i++ {
+		world.Add(world.NewEntity(), &__commandBuffer_Test_C_1{Field1: i + 1})
+	}
+	world.Update()
+
+	s, _ := world.getSystem(TypeOf[__commandBuffer_Test_S_3]())
+	cb := s.(*__commandBuffer_Test_S_3).Commands()
+	set1 := world.getComponentSet(TypeOf[__commandBuffer_Test_C_1]()).(*ComponentSet[__commandBuffer_Test_C_1])
+	set2 := world.getComponentSet(TypeOf[__commandBuffer_Test_C_2]()).(*ComponentSet[__commandBuffer_Test_C_2])
+	if set1.Len() != count*2 || set2.Len() != count {
+		t.Fatalf("components of created entities, got %d %d", set1.Len(), set2.Len())
+	}
+	for i := 1; i <= count; i++ {
+		real, ok := cb.Resolve(Entity(-i))
+		if !ok {
+			t.Fatalf("provisional entity %d not resolved", -i)
+		}
+		c1, c2 := set1.getByEntity(real), set2.getByEntity(real)
+		if c1 == nil || c2 == nil || c1.Field1 != -c2.Field1 {
+			t.Fatalf("components of provisional entity %d", -i)
+		}
+	}
+}
@@ -18,7 +18,8 @@ const (
 type IComponentCollection interface {
 	operate(op CollectionOperate, entity Entity, component IComponent)
 	deleteOperate(op CollectionOperate, entity Entity, it uint16)
-	getTempTasks() []func()
+	getTempTasks(frame uint64) []func()
+	detectChanges(frame uint64)
 	clearDisposable()
 	getComponentSet(typ reflect.Type) IComponentSet
 	getComponentSetByIntType(typ uint16) IComponentSet
@@ -32,6 +33,7 @@ type ComponentCollection struct {
 	bucket      int64
 	locks       []sync.RWMutex
 	opLog       []map[reflect.Type]*opTaskList
+	frame       uint64 // frame of the changes committed by the current flush
 }
 
 func NewComponentCollection(world *ecsWorld, k int) *ComponentCollection {
@@ -160,7 +162,8 @@ func (c *ComponentCollection) clearFree() {
 	}
 }
 
-func (c *ComponentCollection) getTempTasks() []func() {
+func (c *ComponentCollection) getTempTasks(frame uint64) []func() {
+	c.frame = frame
 	combination := make(map[reflect.Type]*opTaskList)
 
 	for i := 0; i < len(c.opLog); i++ {
@@ -193,52 +196,85 @@ func (c *ComponentCollection) getTempTasks() []func() {
 			setp = c.collections.Get(meta.it)
 		}
 
+		presence := newArchetypePresence(c.world.archetypes, meta.it)
 		fn := func() {
-			c.opExecute(taskList, *setp)
+			c.opExecute(taskList, *setp, presence)
 		}
 		tasks = append(tasks, fn)
 	}
 
-	fn := func() {
-		for typ, list := range combination {
-			meta := c.world.getComponentMetaInfoByType(typ)
-			for task := list.head; task != nil; task = task.next {
-				if task.op == CollectionOperateDelete {
-					continue
-				}
-				info, ok := c.world.getEntityInfo(task.target)
-				if ok {
-					switch task.op {
-					case CollectionOperateAdd:
-						switch task.com.getComponentType() {
-						case ComponentTypeNormal, ComponentTypeDisposable:
-							info.addToCompound(meta.it)
-						}
-					case CollectionOperateDelete:
-						switch task.com.getComponentType() {
-						case ComponentTypeNormal, ComponentTypeDisposable:
-							info.removeFromCompound(meta.it)
-						}
-					}
-				}
+	c.updateCompounds(combination)
+	c.world.archetypes.apply()
+	return tasks
+}
+
+// updateCompounds apply the adds and deletes of the flush to the compounds of the entities. It
+// runs serially before the op tasks, the tasks are recycled by opExecute and must not be read
+// by another goroutine in parallel. Deletes are applied too, so that the compound of an entity
+// matches its components after a Remove. The entities whose signature changes are touched for
+// the archetype storage.
+func (c *ComponentCollection) updateCompounds(combination map[reflect.Type]*opTaskList) {
+	for typ, list := range combination {
+		meta := c.world.getComponentMetaInfoByType(typ)
+		if meta.componentType&ComponentTypeFreeMask > 0 {
+			continue
+		}
+		signature := meta.componentType&ComponentTypeDisposableMask == 0
+		for task := list.head; task != nil; task = task.next {
+			info, ok := c.world.getEntityInfo(task.target)
+			if !ok {
+				continue
+			}
+			if signature {
+				c.world.archetypes.touch(task.target)
+			}
+			switch task.op {
+			case CollectionOperateAdd:
+				info.addToCompound(meta.it)
+			case CollectionOperateDelete:
+				info.removeFromCompound(meta.it)
 			}
 		}
 	}
-	tasks = append(tasks, fn)
-	return tasks
 }
 
-func (c *ComponentCollection) opExecute(taskList *opTaskList, collection IComponentSet) {
+// opExecute apply the ops of a component type, presence is not nil in archetype storage mode,
+// the rows have been moved by the archetype storage and an add fills the row of the entity
+func (c *ComponentCollection) opExecute(taskList *opTaskList, collection IComponentSet, presence *archetypePresence) {
 	meta := collection.GetElementMeta()
+	track := c.world.replicator.getTrack(meta.it)
+	exist := func(entity Entity, after bool) bool {
+		if presence == nil {
+			return collection.getPointerByEntity(entity) != nil
+		}
+		e := presence.exist(entity)
+		presence.set(entity, after)
+		return e
+	}
 	for task := taskList.head; task != nil; task = task.next {
 		switch task.op {
 		case CollectionOperateAdd:
+			exist := exist(task.target, true)
 			task.com.setIntType(meta.it)
 			task.com.setOwner(task.target)
-			task.com.addToCollection(task.com.getComponentType(), collection.pointer())
+			if !exist || presence == nil {
+				task.com.addToCollection(task.com.getComponentType(), collection.pointer())
+			}
+			if meta.componentType&ComponentTypeFreeMask == 0 && !exist {
+				collection.recordAdd(task.target, c.frame)
+			}
+			if track != nil {
+				track.onAdd(task.target)
+			}
 		case CollectionOperateDelete:
 			if meta.componentType&ComponentTypeFreeMask == 0 {
-				collection.Remove(task.target)
+				if exist(task.target, false) {
+					collection.Remove(task.target)
+					collection.recordRemove(task.target, c.frame)
+					if track != nil {
+						track.onRemove(task.target)
+					}
+				}
 			}
 		case CollectionOperateDeleteAll:
 			collection.Clear()
@@ -253,6 +289,13 @@ func (c *ComponentCollection) opExecute(taskList *opTaskList, collection ICompon
 	taskList.Reset()
 }
 
+func (c *ComponentCollection) detectChanges(frame uint64) {
+	c.collections.Range(func(set *IComponentSet) bool {
+		(*set).detectChanges(frame)
+		return true
+	})
+}
+
 func (c *ComponentCollection) getComponentSet(typ reflect.Type) IComponentSet {
 	meta := c.world.getComponentMetaInfoByType(typ)
 	return *(c.collections.Get(meta.it))
@@ -272,6 +315,9 @@ func (c *ComponentCollection) checkSet(com IComponent) {
 	isExist := c.collections.Exist(meta.it)
 	if !isExist {
 		set := com.newCollection(meta)
+		if c.world.archetypes != nil && c.world.archetypes.isSignature(meta.it) {
+			set.setView(c.world.archetypes.view(meta.it))
+		}
 		c.collections.Add(set.GetElementMeta().it, &set)
 	}
 }
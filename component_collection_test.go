@@ -0,0 +1,61 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __componentCollection_Test_C_1 struct {
+	Component[__componentCollection_Test_C_1]
+
+	Field1 int
+}
+
+type __componentCollection_Test_C_2 struct {
+	Component[__componentCollection_Test_C_2]
+
+	Field1 int
+}
+
+func TestComponentCollection_updateCompounds(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	world.registerComponent(&__componentCollection_Test_C_1{})
+	world.registerComponent(&__componentCollection_Test_C_2{})
+	world.Startup()
+	it1 := GetComponentMeta[__componentCollection_Test_C_1](world).it
+	it2 := GetComponentMeta[__componentCollection_Test_C_2](world).it
+
+	// enough entities for the op tasks of both types to run in parallel with the compound update
+	var entities []Entity
+	for i := 0; i < 1000; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__componentCollection_Test_C_1{Field1: i}, &__componentCollection_Test_C_2{Field1: i})
+		entities = append(entities, e)
+	}
+	world.Update()
+	for _, e := range entities {
+		info, _ := world.getEntityInfo(e)
+		if !info.Has(it1, it2) {
+			t.Fatalf("entity %d compound missing added components: %v", e, info.compound)
+		}
+	}
+
+	for _, e := range entities[:500] {
+		world.Remove(e, &__componentCollection_Test_C_1{})
+	}
+	world.Update()
+	for i, e := range entities {
+		info, _ := world.getEntityInfo(e)
+		if info.Has(it1) != (i >= 500) || !info.Has(it2) {
+			t.Fatalf("entity %d compound out of sync after remove: %v", e, info.compound)
+		}
+	}
+
+	// a removed component can be added again, the compound no longer reports it present
+	world.Add(entities[0], &__componentCollection_Test_C_1{Field1: 1})
+	world.Update()
+	if info, _ := world.getEntityInfo(entities[0]); !info.Has(it1) {
+		t.Fatal("component not added again after remove")
+	}
+}
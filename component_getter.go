@@ -55,6 +55,7 @@ func (g *GetterCache) Get(key reflect.Type) unsafe.Pointer {
 type ComponentGetter[T ComponentObject] struct {
 	permission ComponentPermission
 	set        *ComponentSet[T]
+	copy       bool
 }
 
 func NewComponentGetter[T ComponentObject](sys ISystem) *ComponentGetter[T] {
@@ -71,13 +72,22 @@ func NewComponentGetter[T ComponentObject](sys ISystem) *ComponentGetter[T] {
 	}
 	getter.set = seti.(*ComponentSet[T])
 	getter.permission = r.getPermission()
+	getter.copy = getter.permission == ComponentReadOnly && sys.World().base().config.AccessCheck
 	return getter
 }
 
 func (c *ComponentGetter[T]) Get(entity Entity) *T {
-	if c.permission == ComponentReadOnly {
-		return &(*c.set.getByEntity(entity))
+	p := c.set.getByEntity(entity)
+	if p == nil {
+		return nil
+	}
+	if c.copy {
+		// writes to the copy do not reach the shared data
+		cpy := *p
+		return &cpy
+	} else if c.permission == ComponentReadOnly {
+		return &(*p)
 	} else {
-		return c.set.getByEntity(entity)
+		return p
 	}
 }
@@ -0,0 +1,122 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ComponentManifestEntry one registered component type
+type ComponentManifestEntry struct {
+	ID     uint16 `json:"id"`
+	Name   string `json:"name"`
+	Size   uint32 `json:"size"`
+	Layout uint64 `json:"layout"`
+}
+
+// ComponentManifest persisted component type registry, used to verify that component ids
+// and memory layouts are identical across builds and restarts
+type ComponentManifest struct {
+	Components []ComponentManifestEntry `json:"components"`
+}
+
+// ExportComponentManifest export all registered component types of the world, ordered by id
+func ExportComponentManifest(world IWorld) *ComponentManifest {
+	meta := world.getComponentMeta()
+	m := &ComponentManifest{}
+	for typ, it := range meta.types {
+		m.Components = append(m.Components, ComponentManifestEntry{
+			ID:     it,
+			Name:   componentTypeName(typ),
+			Size:   uint32(typ.Size()),
+			Layout: componentLayoutHash(typ),
+		})
+	}
+	sort.Slice(m.Components, func(i, j int) bool {
+		return m.Components[i].ID < m.Components[j].ID
+	})
+	return m
+}
+
+// ReadComponentManifest read a manifest written by ComponentManifest.Write
+func ReadComponentManifest(r io.Reader) (*ComponentManifest, error) {
+	m := &ComponentManifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *ComponentManifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Check verify the registered component types of the world against the manifest, reports id
+// collisions, id changes and layout changes. Types missing on either side are ignored.
+func (m *ComponentManifest) Check(world IWorld) error {
+	meta := world.getComponentMeta()
+	byName := map[string]ComponentManifestEntry{}
+	byID := map[uint16]ComponentManifestEntry{}
+	for _, e := range m.Components {
+		byName[e.Name] = e
+		byID[e.ID] = e
+	}
+	for typ, it := range meta.types {
+		name := componentTypeName(typ)
+		if e, ok := byID[it]; ok && e.Name != name {
+			return fmt.Errorf("component id %d collision: %s, manifest: %s", it, name, e.Name)
+		}
+		e, ok := byName[name]
+		if !ok {
+			continue
+		}
+		if e.ID != it {
+			return fmt.Errorf("component %s id changed, manifest: %d, current: %d", name, e.ID, it)
+		}
+		if e.Size != uint32(typ.Size()) || e.Layout != componentLayoutHash(typ) {
+			return fmt.Errorf("component %s layout changed", name)
+		}
+	}
+	return nil
+}
+
+// Bind reserve the ids of the manifest for the component types registered later, by type name,
+// so that the world assigns the same ids as the world the manifest was exported from. The types
+// already registered must have the same ids.
+func (m *ComponentManifest) Bind(world IWorld) error {
+	meta := world.getComponentMeta()
+	for _, e := range m.Components {
+		if err := meta.BindIDByName(e.Name, e.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// componentLayoutHash hashes field names, kinds, offsets and sizes recursively
+func componentLayoutHash(typ reflect.Type) uint64 {
+	h := fnv.New64a()
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		_, _ = h.Write([]byte(t.Kind().String() + ":" + strconv.Itoa(int(t.Size())) + ";"))
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				_, _ = h.Write([]byte(f.Name + "@" + strconv.Itoa(int(f.Offset)) + ";"))
+				walk(f.Type)
+			}
+		case reflect.Array:
+			_, _ = h.Write([]byte(strconv.Itoa(t.Len()) + ";"))
+			walk(t.Elem())
+		}
+	}
+	walk(typ)
+	return h.Sum64()
+}
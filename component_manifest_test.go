@@ -0,0 +1,79 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+)
+
+type __manifest_Test_C_1 struct {
+	Component[__manifest_Test_C_1]
+	Field1 int
+}
+
+type __manifest_Test_C_2 struct {
+	Component[__manifest_Test_C_2]
+	Field1 int
+}
+
+type __manifest_Test_C_3 struct {
+	Component[__manifest_Test_C_3]
+	Field1 int
+}
+
+func TestStableComponentID(t *testing.T) {
+	newWorld := func(reverse bool) *SyncWorld {
+		config := NewDefaultWorldConfig()
+		config.StableComponentID = true
+		world := NewSyncWorld(config)
+		RegisterComponentWithID[__manifest_Test_C_3](world, 7)
+		if reverse {
+			world.registerComponent(&__manifest_Test_C_2{})
+			world.registerComponent(&__manifest_Test_C_1{})
+		} else {
+			world.registerComponent(&__manifest_Test_C_1{})
+			world.registerComponent(&__manifest_Test_C_2{})
+		}
+		return world
+	}
+
+	w1, w2 := newWorld(false), newWorld(true)
+	for _, m := range []*ComponentMetaInfo{
+		GetComponentMeta[__manifest_Test_C_1](w1),
+		GetComponentMeta[__manifest_Test_C_2](w1),
+	} {
+		if w2.getComponentMetaInfoByType(m.typ).it != m.it {
+			t.Errorf("component %s id not stable", m.typ.Name())
+		}
+	}
+	if GetComponentMeta[__manifest_Test_C_3](w1).it != 7 {
+		t.Error("fixed component id not applied")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := ExportComponentManifest(w1).Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ReadComponentManifest(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Check(w2); err != nil {
+		t.Error(err)
+	}
+
+	manifest.Components[0].Layout++
+	if err := manifest.Check(w2); err == nil {
+		t.Error("layout change not detected")
+	}
+}
+
+func TestRegisterComponentWithID_Collision(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterComponentWithID[__manifest_Test_C_1](world, 3)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("id collision not detected")
+		}
+	}()
+	RegisterComponentWithID[__manifest_Test_C_2](world, 3)
+}
@@ -1,7 +1,9 @@
 package ecs
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"reflect"
 )
 
@@ -19,6 +21,9 @@ type ComponentMetaInfo struct {
 type componentMeta struct {
 	world      *ecsWorld
 	seq        uint16
+	stable     bool
+	fixed      map[reflect.Type]uint16
+	named      map[string]uint16
 	types      map[reflect.Type]uint16
 	infos      *SparseArray[uint16, ComponentMetaInfo]
 	disposable map[reflect.Type]uint16
@@ -29,6 +34,9 @@ func NewComponentMeta(world *ecsWorld) *componentMeta {
 	return &componentMeta{
 		world:      world,
 		seq:        0,
+		stable:     world.config.StableComponentID,
+		fixed:      make(map[reflect.Type]uint16),
+		named:      make(map[string]uint16),
 		types:      make(map[reflect.Type]uint16),
 		infos:      NewSparseArray[uint16, ComponentMetaInfo](),
 		disposable: map[reflect.Type]uint16{},
@@ -38,9 +46,8 @@ func NewComponentMeta(world *ecsWorld) *componentMeta {
 
 func (c *componentMeta) CreateComponentMetaInfo(typ reflect.Type, ct ComponentType) *ComponentMetaInfo {
 	c.world.checkMainThread()
-	c.seq++
 	info := &ComponentMetaInfo{}
-	info.it = c.seq
+	info.it = c.allocID(typ)
 	info.componentType = ct
 	info.typ = typ
 
@@ -57,6 +64,106 @@ func (c *componentMeta) CreateComponentMetaInfo(typ reflect.Type, ct ComponentTy
 	return info
 }
 
+// BindID reserves a fixed int type for a component type, must be called before the type is registered
+func (c *componentMeta) BindID(typ reflect.Type, id uint16) {
+	c.world.checkMainThread()
+	if id == 0 {
+		panic("component id 0 is reserved")
+	}
+	if it, ok := c.types[typ]; ok {
+		if it != id {
+			panic(fmt.Sprintf("component %s already registered with id %d", typ.String(), it))
+		}
+		return
+	}
+	if name, ok := c.reserved(id); ok && name != componentTypeName(typ) {
+		panic(fmt.Sprintf("component id %d collision: %s, %s", id, name, typ.String()))
+	}
+	if info := c.infos.Get(id); info != nil {
+		panic(fmt.Sprintf("component id %d collision: %s, %s", id, info.typ.String(), typ.String()))
+	}
+	c.fixed[typ] = id
+}
+
+// BindIDByName reserves a fixed int type for a component type not yet known by the process, such
+// as the component types of a snapshot, BindID takes precedence
+func (c *componentMeta) BindIDByName(name string, id uint16) error {
+	c.world.checkMainThread()
+	if id == 0 {
+		return errors.New("component id 0 is reserved")
+	}
+	for typ, it := range c.types {
+		if componentTypeName(typ) == name {
+			if it != id {
+				return fmt.Errorf("component %s already registered with id %d, bind %d before registering it", name, it, id)
+			}
+			return nil
+		}
+	}
+	if reserved, ok := c.reserved(id); ok && reserved != name {
+		return fmt.Errorf("component id %d collision: %s, %s", id, reserved, name)
+	}
+	if info := c.infos.Get(id); info != nil {
+		return fmt.Errorf("component id %d collision: %s, %s", id, info.typ.String(), name)
+	}
+	c.named[name] = id
+	return nil
+}
+
+// reserved the name of the component type the id is bound to
+func (c *componentMeta) reserved(id uint16) (string, bool) {
+	for t, it := range c.fixed {
+		if it == id {
+			return componentTypeName(t), true
+		}
+	}
+	for name, it := range c.named {
+		if it == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (c *componentMeta) allocID(typ reflect.Type) uint16 {
+	if id, ok := c.fixed[typ]; ok {
+		return id
+	}
+	name := componentTypeName(typ)
+	if id, ok := c.named[name]; ok {
+		return id
+	}
+	if c.stable {
+		id := stableComponentID(typ)
+		if info := c.infos.Get(id); info != nil {
+			panic(fmt.Sprintf("component id %d collision: %s, %s, bind one of them with RegisterComponentWithID",
+				id, info.typ.String(), typ.String()))
+		}
+		if reserved, ok := c.reserved(id); ok {
+			panic(fmt.Sprintf("component id %d collision: %s, %s, bind one of them with RegisterComponentWithID",
+				id, reserved, typ.String()))
+		}
+		return id
+	}
+	for {
+		c.seq++
+		if c.infos.Exist(c.seq) {
+			continue
+		}
+		if _, ok := c.reserved(c.seq); !ok {
+			return c.seq
+		}
+	}
+}
+
+// stableComponentID hashes the full type name into [1, 0xFFFE]
+func stableComponentID(typ reflect.Type) uint16 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(componentTypeName(typ)))
+	sum := h.Sum32()
+	return uint16((sum^(sum>>16))%0xFFFE) + 1
+}
+
 func (c *componentMeta) GetDisposableTypes() map[reflect.Type]uint16 {
 	return c.disposable
 }
@@ -106,25 +213,25 @@ func (c *componentMeta) ComponentMetaInfoPrint() {
 			count++
 			if count%5 == 0 {
 				if count == total {
-					Log.Infof("%s%s", prefix2, str)
+					c.world.logger.Infof("%s%s", prefix2, str)
 				} else {
-					Log.Infof("%s%s", prefix, str)
+					c.world.logger.Infof("%s%s", prefix, str)
 				}
 				str = ""
 			}
 		}
 		if str != "" {
-			Log.Infof("%s%s", prefix2, str)
+			c.world.logger.Infof("%s%s", prefix2, str)
 			str = ""
 		}
 	}
 
-	Log.Infof("┌──────────────── # Component Info # ─────────────────")
-	Log.Infof("├─ Total: %d", len(c.types))
+	c.world.logger.Infof("┌──────────────── # Component Info # ─────────────────")
+	c.world.logger.Infof("├─ Total: %d", len(c.types))
 	fn(c.types)
-	Log.Infof("├─ Disposable: %d", len(c.disposable))
+	c.world.logger.Infof("├─ Disposable: %d", len(c.disposable))
 	fn(c.disposable)
-	Log.Infof("├─ Free: %d", len(c.free))
+	c.world.logger.Infof("├─ Free: %d", len(c.free))
 	fn(c.free)
-	Log.Infof("└────────────── # Component Info End # ───────────────")
+	c.world.logger.Infof("└────────────── # Component Info End # ───────────────")
 }
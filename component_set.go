@@ -1,6 +1,8 @@
 package ecs
 
 import (
+	"hash/fnv"
+	"io"
 	"sort"
 	"unsafe"
 )
@@ -19,14 +21,26 @@ type IComponentSet interface {
 	getPointerByIndex(index int64) unsafe.Pointer
 	changeCount() int64
 	changeReset()
+	checksum() uint64
 	pointer() unsafe.Pointer
 	getPointerByEntity(entity Entity) unsafe.Pointer
+	serialize(w io.Writer) error
+	deserialize(r io.Reader) error
+	trackChanges(reader ISystem)
+	getChangeLog() *changeLog
+	recordAdd(entity Entity, frame uint64)
+	recordRemove(entity Entity, frame uint64)
+	detectChanges(frame uint64)
+	setView(view *archetypeView)
 }
 
 type ComponentSet[T ComponentObject] struct {
 	SparseArray[int32, T]
-	change int64
-	meta   *ComponentMetaInfo
+	change  int64
+	meta    *ComponentMetaInfo
+	changes *changeLog
+	shadow  *SparseArray[int32, T]
+	view    *archetypeView // components stored in the archetype tables
 }
 
 func NewComponentSet[T ComponentObject](meta *ComponentMetaInfo, initSize ...int) *ComponentSet[T] {
@@ -37,7 +51,29 @@ func NewComponentSet[T ComponentObject](meta *ComponentMetaInfo, initSize ...int
 	return c
 }
 
+// setView store the components in the archetype tables, the rows are moved by the archetype
+// storage and Add only fills the row of the entity
+func (c *ComponentSet[T]) setView(view *archetypeView) {
+	c.view = view
+}
+
+func (c *ComponentSet[T]) Len() int {
+	if c.view != nil {
+		return c.view.len
+	}
+	return c.SparseArray.Len()
+}
+
 func (c *ComponentSet[T]) Add(element *T, entity Entity) *T {
+	if c.view != nil {
+		data := (*T)(c.view.get(entity))
+		if data == nil {
+			return nil
+		}
+		*data = *element
+		c.change++
+		return data
+	}
 	index := entity.ToRealID().index
 	data := c.SparseArray.Add(index, element)
 	if data == nil {
@@ -48,8 +84,10 @@ func (c *ComponentSet[T]) Add(element *T, entity Entity) *T {
 }
 
 func (c *ComponentSet[T]) remove(entity Entity) *T {
-	index := entity.ToRealID().index
-	return c.SparseArray.Remove(index)
+	if c.view != nil || c.getByEntity(entity) == nil {
+		return nil
+	}
+	return c.SparseArray.Remove(entity.ToRealID().index)
 }
 
 func (c *ComponentSet[T]) Remove(entity Entity) {
@@ -65,8 +103,18 @@ func (c *ComponentSet[T]) RemoveAndReturn(entity Entity) *T {
 	return &cpy
 }
 
+// getByEntity the owner is checked, a stale entity does not resolve to the entity reusing its index
 func (c *ComponentSet[T]) getByEntity(entity Entity) *T {
-	return c.SparseArray.Get(entity.ToRealID().index)
+	var p *T
+	if c.view != nil {
+		p = (*T)(c.view.get(entity))
+	} else {
+		p = c.SparseArray.Get(entity.ToRealID().index)
+	}
+	if p == nil || (*Component[T])(unsafe.Pointer(p)).owner != entity {
+		return nil
+	}
+	return p
 }
 
 func (c *ComponentSet[T]) getPointerByEntity(entity Entity) unsafe.Pointer {
@@ -93,27 +141,131 @@ func (c *ComponentSet[T]) changeReset() {
 	c.change = 0
 }
 
+// checksum hash of the raw component data, used to find the writes in a batch
+func (c *ComponentSet[T]) checksum() uint64 {
+	h := fnv.New64a()
+	if c.view != nil {
+		for _, run := range c.view.runs {
+			h.Write(unsafe.Slice((*byte)(run.base), uintptr(run.count)*c.view.size))
+		}
+		return h.Sum64()
+	}
+	if n := c.Len(); n > 0 {
+		size := int(TypeOf[T]().Size())
+		h.Write(unsafe.Slice((*byte)(unsafe.Pointer(&c.data[0])), n*size))
+	}
+	return h.Sum64()
+}
+
+// Clear in archetype storage mode the rows are owned by the tables, only the changes are cleared
+func (c *ComponentSet[T]) Clear() {
+	c.SparseArray.Clear()
+	if c.shadow != nil {
+		c.shadow.Clear()
+	}
+}
+
+func (c *ComponentSet[T]) trackChanges(reader ISystem) {
+	if c.changes == nil {
+		c.changes = &changeLog{}
+		c.shadow = NewSparseArray[int32, T]()
+		c.rangeData(func(com *T) bool {
+			c.shadow.Add((*Component[T])(unsafe.Pointer(com)).owner.ToRealID().index, com)
+			return true
+		})
+	}
+	for _, r := range c.changes.readers {
+		if r == reader {
+			return
+		}
+	}
+	c.changes.readers = append(c.changes.readers, reader)
+}
+
+func (c *ComponentSet[T]) getChangeLog() *changeLog {
+	return c.changes
+}
+
+func (c *ComponentSet[T]) recordAdd(entity Entity, frame uint64) {
+	if c.changes == nil {
+		return
+	}
+	// nil for a component added and removed by the same flush in archetype storage mode
+	if data := c.getByEntity(entity); data != nil {
+		index := entity.ToRealID().index
+		if p := c.shadow.Get(index); p != nil {
+			*p = *data
+		} else {
+			c.shadow.Add(index, data)
+		}
+	}
+	c.changes.record(entity, frame, changeKindAdded)
+}
+
+func (c *ComponentSet[T]) recordRemove(entity Entity, frame uint64) {
+	if c.changes == nil {
+		return
+	}
+	c.shadow.Remove(entity.ToRealID().index)
+	c.changes.record(entity, frame, changeKindRemoved)
+}
+
+// detectChanges compare all components with the shadow copies taken at the last detection
+func (c *ComponentSet[T]) detectChanges(frame uint64) {
+	if c.changes == nil {
+		return
+	}
+	c.rangeData(func(com *T) bool {
+		cp := (*Component[T])(unsafe.Pointer(com))
+		index := cp.owner.ToRealID().index
+		sp := c.shadow.Get(index)
+		if sp == nil {
+			c.shadow.Add(index, com)
+			return true
+		}
+		if componentDataEqual(unsafe.Pointer(cp), unsafe.Pointer(sp), c.eleSize) {
+			return true
+		}
+		*sp = *com
+		c.changes.record(cp.owner, frame, changeKindModified)
+		return true
+	})
+	c.changes.prune()
+}
+
+// Sort order the components by seq, components without seq keep their order at the end
 func (c *ComponentSet[T]) Sort() {
-	if c.changeCount() == 0 {
+	if c.view != nil {
+		// the order is the order of the archetype tables
+		c.changeReset()
 		return
 	}
-	var zeroSeq = SeqMax
-	seq2id := map[uint32]int64{}
-	var cp *Component[T]
-	for i := int64(0); i < int64(c.Len()); i++ {
-		cp = (*Component[T])(unsafe.Pointer(&(c.data[i])))
-		if cp.seq == 0 {
-			zeroSeq--
-			cp.seq = zeroSeq
+	n := c.Len()
+	data := c.data[:n]
+	seqOf := func(i int) uint32 {
+		if seq := (*Component[T])(unsafe.Pointer(&data[i])).seq; seq != 0 {
+			return seq
+		}
+		return SeqMax
+	}
+	ordered := true
+	for i := 1; i < n; i++ {
+		if seqOf(i-1) > seqOf(i) {
+			ordered = false
+			break
 		}
-		seq2id[cp.seq] = cp.owner.ToInt64()
 	}
-	sort.Slice(c.data, func(i, j int) bool {
-		return (*Component[T])(unsafe.Pointer(&(c.data[i]))).seq < (*Component[T])(unsafe.Pointer(&(c.data[j]))).seq
+	if ordered {
+		c.changeReset()
+		return
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return seqOf(i) < seqOf(j)
 	})
-	for i := int32(0); i < int32(c.Len()); i++ {
-		cp = (*Component[T])(unsafe.Pointer(&(c.data[i])))
-		c.indices[cp.owner.ToRealID().index] = i + 1
+	for i := int32(0); i < int32(n); i++ {
+		index := (*Component[T])(unsafe.Pointer(&data[i])).owner.ToRealID().index
+		c.indices[index] = i + 1
+		c.idx2Key[i] = index
 	}
 	c.changeReset()
 }
@@ -127,6 +279,9 @@ func (c *ComponentSet[T]) GetComponentRaw(entity Entity) unsafe.Pointer {
 }
 
 func (c *ComponentSet[T]) getPointerByIndex(index int64) unsafe.Pointer {
+	if c.view != nil {
+		return c.view.getByIndex(int(index))
+	}
 	return unsafe.Pointer(c.SparseArray.UnorderedCollection.Get(index))
 }
 
@@ -135,12 +290,90 @@ func (c *ComponentSet[T]) GetElementMeta() *ComponentMetaInfo {
 }
 
 func (c *ComponentSet[T]) Range(fn func(com IComponent) bool) {
-	c.SparseArray.Range(func(com *T) bool {
+	c.rangeData(func(com *T) bool {
 		return fn(any(com).(IComponent))
 	})
 }
 
+func (c *ComponentSet[T]) rangeData(fn func(com *T) bool) {
+	if c.view == nil {
+		c.SparseArray.Range(fn)
+		return
+	}
+	for _, run := range c.view.runs {
+		for i := 0; i < run.count; i++ {
+			if !fn((*T)(unsafe.Add(run.base, uintptr(i)*c.view.size))) {
+				return
+			}
+		}
+	}
+}
+
+func (c *ComponentSet[T]) serialize(w io.Writer) error {
+	sw := &snapshotWriter{w: w}
+	sw.i64(int64(c.Len()))
+	if c.Len() == 0 {
+		return sw.err
+	}
+	if _, ok := any(new(T)).(ICustomSerialize); ok {
+		c.rangeData(func(com *T) bool {
+			sw.i64(int64((*Component[T])(unsafe.Pointer(com)).owner))
+			sw.bytes(any(com).(ICustomSerialize).Serialize())
+			return sw.err == nil
+		})
+		return sw.err
+	}
+	if c.view != nil {
+		for _, run := range c.view.runs {
+			sw.write(rawBytes((*T)(run.base), uintptr(run.count)*c.eleSize))
+		}
+		return sw.err
+	}
+	sw.write(rawBytes(&c.data[0], uintptr(c.Len())*c.eleSize))
+	return sw.err
+}
+
+func (c *ComponentSet[T]) deserialize(r io.Reader) error {
+	sr := &snapshotReader{r: r}
+	n := sr.i64()
+	ins := new(T)
+	_, custom := any(ins).(ICustomSerialize)
+	free := c.meta.componentType&ComponentTypeFreeMask > 0
+	for i := int64(0); i < n && sr.err == nil; i++ {
+		*ins = *new(T)
+		cp := (*Component[T])(unsafe.Pointer(ins))
+		if custom {
+			owner := Entity(sr.i64())
+			b := sr.bytes()
+			if sr.err != nil {
+				break
+			}
+			any(ins).(ICustomSerialize).DeSerialize(b)
+			cp.owner = owner
+		} else {
+			sr.read(rawBytes(ins, c.eleSize))
+			if sr.err != nil {
+				break
+			}
+		}
+		cp.it = c.meta.it
+		var added *T
+		if free {
+			added, _ = c.UnorderedCollection.Add(ins)
+		} else {
+			added = c.Add(ins, cp.owner)
+		}
+		if added != nil {
+			(*Component[T])(unsafe.Pointer(added)).setState(ComponentStateActive)
+		}
+	}
+	return sr.err
+}
+
 func NewComponentSetIterator[T ComponentObject](collection *ComponentSet[T], readOnly ...bool) Iterator[T] {
+	if collection.view != nil {
+		return newArchetypeColumnIter[T](collection.view.runs, collection.view.size, len(readOnly) > 0 && readOnly[0])
+	}
 	iter := &Iter[T]{
 		data:    collection.data,
 		len:     collection.Len(),
@@ -5,14 +5,30 @@ import (
 	"unsafe"
 )
 
-func RegisterSystem[T SystemObject, TP SystemPointer[T]](world IWorld, order ...Order) {
+// RegisterSystem register a system with options, such as an Order, WithInterval or WithFrameDivisor,
+// while the world is running the system is added before the next frame and starts in it
+func RegisterSystem[T SystemObject, TP SystemPointer[T]](world IWorld, options ...SystemOption) {
 	sys := TP(new(T))
-	if len(order) > 0 {
-		sys.setOrder(order[0])
+	for _, option := range options {
+		option.applyTo(sys)
 	}
 	world.registerSystem(sys)
 }
 
+// UnregisterSystem remove a system, while the world is running the system runs its destroy stages in
+// the next frame and is removed after it. Unregister and register the same type to hot swap a system
+func UnregisterSystem[T SystemObject](world IWorld) {
+	world.unregisterSystem(TypeOf[T]())
+}
+
+// RegisterComponentWithID bind a fixed int type to a component type, so that snapshots, replication
+// and logs refer to the same id across processes. Must be called before any system requires it.
+func RegisterComponentWithID[T ComponentObject, TP ComponentPointer[T]](world IWorld, id uint16) {
+	meta := world.getComponentMeta()
+	meta.BindID(TypeOf[T](), id)
+	world.getOrCreateComponentMetaInfo(TP(new(T)))
+}
+
 func AddFreeComponent[T FreeComponentObject, TP FreeComponentPointer[T]](world IWorld, component *T) {
 	world.addFreeComponent(TP(component))
 }
@@ -31,6 +47,7 @@ func GetComponentAll[T ComponentObject](sys ISystem) Iterator[T] {
 	typ := GetType[T]()
 	r, ok := sys.GetRequirements()[typ]
 	if !ok {
+		reportUndeclared(sys, typ)
 		return EmptyIter[T]()
 	}
 
@@ -45,6 +62,7 @@ func GetRelated[T ComponentObject](sys ISystem, entity Entity) *T {
 	typ := TypeOf[T]()
 	isRequire := sys.isRequire(typ)
 	if !isRequire {
+		reportUndeclared(sys, typ)
 		return nil
 	}
 	var cache *ComponentGetter[T]
@@ -6,11 +6,29 @@ type EntityInfo struct {
 }
 
 func (e *EntityInfo) Destroy(world IWorld) {
+	entity := e.entity
 	for i := 0; i < len(e.compound); i++ {
-		world.deleteComponentByIntType(e.entity, e.compound[i])
+		world.deleteComponentByIntType(entity, e.compound[i])
 	}
-	// must be last
-	world.deleteEntity(e.entity)
+	// must be last, e is invalid after the entity is removed
+	world.deleteEntity(entity)
+
+	// cascade to the children
+	children := world.base().hierarchy.getChildren(entity)
+	for _, child := range append([]Entity(nil), children...) {
+		if info, ok := world.getEntityInfo(child); ok {
+			info.Destroy(world)
+		}
+	}
+}
+
+// SetParent take effect at the next flush like component operations, the child is destroyed with the parent
+func (e *EntityInfo) SetParent(world IWorld, parent Entity) {
+	world.base().hierarchy.operate(hierarchyOperateSetParent, e.entity, parent)
+}
+
+func (e *EntityInfo) RemoveParent(world IWorld) {
+	world.base().hierarchy.operate(hierarchyOperateRemoveParent, e.entity, 0)
 }
 
 func (e *EntityInfo) Entity() Entity {
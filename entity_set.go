@@ -11,14 +11,15 @@ func NewEntityCollection() *EntitySet {
 }
 
 func (c *EntitySet) Exist(entity Entity) bool {
-	index := entity.ToRealID().index
-	return c.SparseArray.Exist(index)
+	_, ok := c.GetEntityInfo(entity)
+	return ok
 }
 
+// GetEntityInfo the generation of the entity is checked, stale entities are not found
 func (c *EntitySet) GetEntityInfo(entity Entity) (*EntityInfo, bool) {
 	index := entity.ToRealID().index
 	info := c.Get(index)
-	if info == nil {
+	if info == nil || info.entity != entity {
 		return nil, false
 	}
 	return info, true
@@ -30,6 +31,8 @@ func (c *EntitySet) Add(entityInfo EntityInfo) *EntityInfo {
 }
 
 func (c *EntitySet) Remove(entity Entity) *EntityInfo {
-	index := entity.ToRealID().index
-	return c.SparseArray.Remove(index)
+	if !c.Exist(entity) {
+		return nil
+	}
+	return c.SparseArray.Remove(entity.ToRealID().index)
 }
@@ -0,0 +1,153 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+type iEventQueue interface {
+	swap()
+}
+
+// eventQueue queue of one event type, events written in a frame are readable by the later stages of
+// the frame and by the whole next frame, and kept longer until every reader has read them, so that a
+// reader of a system running at a lower tick rate does not miss events
+type eventQueue[E any] struct {
+	lock       sync.Mutex
+	events     []E
+	base       uint64 // sequence of events[0]
+	frameStart uint64 // sequence of the first event of the current frame
+	readers    []*EventReader[E]
+}
+
+func (q *eventQueue[E]) send(events ...E) {
+	q.lock.Lock()
+	q.events = append(q.events, events...)
+	q.lock.Unlock()
+}
+
+// read the events of a reader from its cursor and move the cursor to the end
+func (q *eventQueue[E]) read(r *EventReader[E]) []E {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	cursor := r.cursor
+	if cursor < q.base {
+		cursor = q.base
+	}
+	end := q.base + uint64(len(q.events))
+	r.cursor = end
+	return q.events[cursor-q.base : end-q.base : end-q.base]
+}
+
+// addReader a reader created again by Init, e.g. when the system restarts, replaces the previous
+// reader of the system
+func (q *eventQueue[E]) addReader(r *EventReader[E]) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i, reader := range q.readers {
+		if reader.sys == r.sys {
+			q.readers[i] = r
+			return
+		}
+	}
+	q.readers = append(q.readers, r)
+}
+
+// swap drop the events of the previous frame read by all the readers
+func (q *eventQueue[E]) swap() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	keep := q.frameStart
+	readers := q.readers[:0]
+	for _, r := range q.readers {
+		// removed systems stop holding back the events
+		if r.sys.getState() == SystemStateDestroyed {
+			continue
+		}
+		readers = append(readers, r)
+		if r.cursor < keep {
+			keep = r.cursor
+		}
+	}
+	q.readers = readers
+	if keep < q.base {
+		keep = q.base
+	}
+	if drop := keep - q.base; drop > 0 {
+		q.events = append(make([]E, 0, len(q.events)-int(drop)), q.events[drop:]...)
+		q.base = keep
+	}
+	q.frameStart = q.base + uint64(len(q.events))
+}
+
+type eventQueues struct {
+	queues map[reflect.Type]iEventQueue
+}
+
+func newEventQueues() *eventQueues {
+	return &eventQueues{
+		queues: map[reflect.Type]iEventQueue{},
+	}
+}
+
+func (e *eventQueues) swap() {
+	for _, q := range e.queues {
+		q.swap()
+	}
+}
+
+func getEventQueue[E any](world *ecsWorld) *eventQueue[E] {
+	typ := TypeOf[E]()
+	q, ok := world.events.queues[typ]
+	if !ok {
+		q = &eventQueue[E]{}
+		world.events.queues[typ] = q
+	}
+	return q.(*eventQueue[E])
+}
+
+// EventWriter send events of type E to all readers
+type EventWriter[E any] struct {
+	queue *eventQueue[E]
+}
+
+// NewEventWriter declare in Init that the system writes events of type E, systems writing and reading
+// the same event type are not executed in the same batch
+func NewEventWriter[E any](initializer SystemInitConstraint) *EventWriter[E] {
+	if initializer.isValid() {
+		panic("out of initialization stage")
+	}
+	sys := initializer.getSystem()
+	sys.setEventAccess(TypeOf[E](), ComponentReadWrite)
+	return &EventWriter[E]{queue: getEventQueue[E](sys.World().base())}
+}
+
+func (w *EventWriter[E]) Send(events ...E) {
+	w.queue.send(events...)
+}
+
+// EventReader read events of type E, each reader has its own cursor
+type EventReader[E any] struct {
+	queue  *eventQueue[E]
+	sys    ISystem
+	cursor uint64 // guarded by the lock of the queue
+}
+
+// NewEventReader declare in Init that the system reads events of type E
+func NewEventReader[E any](initializer SystemInitConstraint) *EventReader[E] {
+	if initializer.isValid() {
+		panic("out of initialization stage")
+	}
+	sys := initializer.getSystem()
+	sys.setEventAccess(TypeOf[E](), ComponentReadOnly)
+	r := &EventReader[E]{queue: getEventQueue[E](sys.World().base()), sys: sys}
+	r.queue.addReader(r)
+	return r
+}
+
+// Read get the events not read by this reader yet, the events are kept until the reader reads
+// them, also when its system runs at a lower tick rate or is paused. The returned slice must not
+// be modified or retained
+func (r *EventReader[E]) Read() []E {
+	return r.queue.read(r)
+}
@@ -0,0 +1,137 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __eventQueue_Test_Died struct {
+	Frame uint64
+}
+
+type __eventQueue_Test_C_1 struct {
+	Component[__eventQueue_Test_C_1]
+}
+
+type __eventQueue_Test_S_Writer struct {
+	System[__eventQueue_Test_S_Writer]
+
+	writer *EventWriter[__eventQueue_Test_Died]
+}
+
+func (s *__eventQueue_Test_S_Writer) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__eventQueue_Test_C_1]{})
+	s.writer = NewEventWriter[__eventQueue_Test_Died](si)
+	return nil
+}
+
+func (s *__eventQueue_Test_S_Writer) Update(event Event) {
+	s.writer.Send(__eventQueue_Test_Died{Frame: event.Frame})
+}
+
+type __eventQueue_Test_S_Reader struct {
+	System[__eventQueue_Test_S_Reader]
+
+	reader   *EventReader[__eventQueue_Test_Died]
+	received []uint64
+}
+
+func (s *__eventQueue_Test_S_Reader) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__eventQueue_Test_C_1]{})
+	s.reader = NewEventReader[__eventQueue_Test_Died](si)
+	return nil
+}
+
+func (s *__eventQueue_Test_S_Reader) Update(event Event) {
+	for _, e := range s.reader.Read() {
+		s.received = append(s.received, e.Frame)
+	}
+}
+
+type __eventQueue_Test_S_PostReader struct {
+	System[__eventQueue_Test_S_PostReader]
+
+	reader   *EventReader[__eventQueue_Test_Died]
+	received []uint64
+	frames   []uint64
+}
+
+func (s *__eventQueue_Test_S_PostReader) Init(si SystemInitConstraint) error {
+	s.reader = NewEventReader[__eventQueue_Test_Died](si)
+	return nil
+}
+
+func (s *__eventQueue_Test_S_PostReader) PostUpdate(event Event) {
+	for _, e := range s.reader.Read() {
+		s.received = append(s.received, e.Frame)
+		s.frames = append(s.frames, event.Frame)
+	}
+}
+
+func TestNewEventWriter(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__eventQueue_Test_S_Writer](world)
+	RegisterSystem[__eventQueue_Test_S_Reader](world)
+	RegisterSystem[__eventQueue_Test_S_PostReader](world)
+	world.Startup()
+
+	// writer and reader share only a read-only component, the events split them into batches
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		for ss := sg.Begin(); !sg.End(); ss = sg.Next() {
+			if len(ss) > 1 {
+				t.Fatal("event writer and reader in the same batch")
+			}
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		world.Update()
+	}
+
+	s, _ := world.getSystem(TypeOf[__eventQueue_Test_S_PostReader]())
+	post := s.(*__eventQueue_Test_S_PostReader)
+	if len(post.received) != 4 {
+		t.Fatalf("post update reader, got %v", post.received)
+	}
+	for i := range post.received {
+		if post.received[i] != post.frames[i] {
+			t.Fatalf("event not readable in the same frame, got %v at %v", post.received, post.frames)
+		}
+	}
+
+	s, _ = world.getSystem(TypeOf[__eventQueue_Test_S_Reader]())
+	reader := s.(*__eventQueue_Test_S_Reader)
+	if len(reader.received) < 3 {
+		t.Fatalf("update reader, got %v", reader.received)
+	}
+	for i := 1; i < len(reader.received); i++ {
+		if reader.received[i] != reader.received[i-1]+1 {
+			t.Fatalf("events read twice or missed, got %v", reader.received)
+		}
+	}
+}
+
+func Test_eventQueue_swap(t *testing.T) {
+	q := &eventQueue[int]{}
+	q.send(1, 2)
+	q.swap()
+	q.send(3)
+
+	// a reader not registered to the queue does not hold back the events
+	r := &EventReader[int]{queue: q}
+	if events := q.read(r); len(events) != 3 {
+		t.Fatalf("events, got %v", events)
+	}
+	if events := q.read(r); len(events) != 0 {
+		t.Fatalf("read twice, got %v", events)
+	}
+
+	// events of the frame before last are dropped
+	q.swap()
+	if events := q.read(&EventReader[int]{queue: q}); len(events) != 1 || events[0] != 3 {
+		t.Fatalf("events after swap, got %v", events)
+	}
+	q.swap()
+	if events := q.read(r); len(events) != 0 {
+		t.Fatalf("events after the second swap, got %v", events)
+	}
+}
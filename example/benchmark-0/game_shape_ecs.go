@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/zllangct/ecs"
+)
+
+const (
+	ShapeEntityCount = 100000
+	ShapeChangeCount = 100
+)
+
+// GameShapeECS many entities over a few archetypes, the systems iterate with shapes, a few
+// entities change their archetype every frame
+type GameShapeECS struct {
+	world    *ecs.SyncWorld
+	entities []ecs.Entity
+	frame    int
+}
+
+func (g *GameShapeECS) init(config *ecs.WorldConfig) {
+	g.world = ecs.NewSyncWorld(config)
+
+	ecs.RegisterSystem[ShapeMoveSystem](g.world)
+	ecs.RegisterSystem[ShapeHealSystem](g.world)
+	ecs.RegisterSystem[ShapeMarkSystem](g.world)
+
+	for i := 0; i < ShapeEntityCount; i++ {
+		e := g.world.NewEntity()
+		g.world.Add(e, &Position{}, &Movement{V: 1, Dir: [3]int{1, 0, 0}})
+		if i%2 == 0 {
+			g.world.Add(e, &HealthPoint{HP: 100})
+		}
+		if i%3 == 0 {
+			g.world.Add(e, &Test1{})
+		}
+		g.entities = append(g.entities, e)
+	}
+}
+
+// change add or remove a component of a few entities, moving them to another archetype
+func (g *GameShapeECS) change() {
+	for i := 0; i < ShapeChangeCount; i++ {
+		e := g.entities[(g.frame*ShapeChangeCount+i)%len(g.entities)]
+		if g.frame%2 == 0 {
+			g.world.Remove(e, &Test1{})
+		} else {
+			g.world.Add(e, &Test1{})
+		}
+	}
+	g.frame++
+}
@@ -65,6 +65,35 @@ func BenchmarkEcs(b *testing.B) {
 	}
 }
 
+func benchmarkEcsShape(b *testing.B, archetype bool) {
+	game := &GameShapeECS{}
+	config := ecs.NewDefaultWorldConfig()
+	config.Debug = false
+	config.MetaInfoDebugPrint = false
+	config.ArchetypeStorage = archetype
+	game.init(config)
+
+	game.world.Startup()
+	game.world.Update()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		game.change()
+		game.world.Update()
+	}
+}
+
+// BenchmarkEcsShape shape iteration and archetype changes in the default sparse set storage
+func BenchmarkEcsShape(b *testing.B) {
+	benchmarkEcsShape(b, false)
+}
+
+// BenchmarkEcsShapeArchetype the same world in archetype storage mode
+func BenchmarkEcsShapeArchetype(b *testing.B) {
+	benchmarkEcsShape(b, true)
+}
+
 func BenchmarkEcsSingleCore(b *testing.B) {
 	//go func() {
 	//	http.ListenAndServe(":6060", nil)
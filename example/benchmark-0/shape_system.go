@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/zllangct/ecs"
+)
+
+type Mover struct {
+	Position *Position
+	Movement *Movement
+}
+
+type Wounded struct {
+	Position    *Position
+	HealthPoint *HealthPoint
+}
+
+// ShapeMoveSystem moves the entities with a shape, iterated by archetype in archetype storage mode
+type ShapeMoveSystem struct {
+	ecs.System[ShapeMoveSystem]
+	mover *ecs.Shape[Mover]
+}
+
+func (m *ShapeMoveSystem) Init(si ecs.SystemInitConstraint) error {
+	m.SetRequirements(si, &Position{}, &ecs.ReadOnly[Movement]{})
+	m.mover = ecs.NewShape[Mover](si)
+	return nil
+}
+
+func (m *ShapeMoveSystem) Update(event ecs.Event) {
+	iter := m.mover.Get()
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		v.Position.X += v.Movement.Dir[0] * v.Movement.V
+		v.Position.Y += v.Movement.Dir[1] * v.Movement.V
+		v.Position.Z += v.Movement.Dir[2] * v.Movement.V
+	}
+}
+
+// ShapeHealSystem heals the entities near the origin
+type ShapeHealSystem struct {
+	ecs.System[ShapeHealSystem]
+	wounded *ecs.Shape[Wounded]
+}
+
+func (h *ShapeHealSystem) Init(si ecs.SystemInitConstraint) error {
+	h.SetRequirements(si, &ecs.ReadOnly[Position]{}, &HealthPoint{})
+	h.wounded = ecs.NewShape[Wounded](si)
+	return nil
+}
+
+func (h *ShapeHealSystem) Update(event ecs.Event) {
+	iter := h.wounded.Get()
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		if v.Position.X%1000 < 500 {
+			v.HealthPoint.HP++
+		}
+	}
+}
+
+type Marked struct {
+	Movement *Movement
+	Test1    *Test1
+}
+
+// ShapeMarkSystem counts the moves of the marked entities, the entities change the mark every frame
+type ShapeMarkSystem struct {
+	ecs.System[ShapeMarkSystem]
+	marked *ecs.Shape[Marked]
+}
+
+func (m *ShapeMarkSystem) Init(si ecs.SystemInitConstraint) error {
+	m.SetRequirements(si, &ecs.ReadOnly[Movement]{}, &Test1{})
+	m.marked = ecs.NewShape[Marked](si)
+	return nil
+}
+
+func (m *ShapeMarkSystem) Update(event ecs.Event) {
+	iter := m.marked.Get()
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		v.Test1.Test1 += v.Movement.V
+	}
+}
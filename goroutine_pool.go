@@ -2,6 +2,7 @@ package ecs
 
 import (
 	runtime2 "runtime"
+	"runtime/debug"
 )
 
 // Worker goroutine struct.
@@ -14,12 +15,18 @@ type Worker struct {
 // Start goroutine pool.
 func (w *Worker) Start() {
 	c := func() (c bool) {
-		//defer func() {
-		//	if r := recover(); r != nil {
-		//		Log.Error(r)
-		//	}
-		//	c = true
-		//}()
+		// a panic escaping a job is reported to the owner of the pool, it crashes the process if the
+		// owner says so, otherwise the worker restarts
+		defer func() {
+			if r := recover(); r != nil {
+				if w.p.onPanic == nil {
+					NewFieldLogger(nil).Log(LogLevelError, "worker job panicked", "panic", r, "stack", string(debug.Stack()))
+				} else if w.p.onPanic(r, debug.Stack()) {
+					panic(r)
+				}
+				c = true
+			}
+		}()
 		var job func()
 		for {
 			select {
@@ -42,6 +49,7 @@ type Pool struct {
 	jobQueueSize uint32
 	jobQueue     chan func()
 	workers      []*Worker
+	onPanic      func(r any, stack []byte) bool // report a panic of a job, true to crash
 }
 
 // NewPool news goroutine pool
@@ -83,6 +91,16 @@ func (p *Pool) Add(job func(), hashKey ...uint32) {
 	p.jobQueue <- job
 }
 
+// TryAdd add a job without blocking, false if the job queue is full
+func (p *Pool) TryAdd(job func()) bool {
+	select {
+	case p.jobQueue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
 // Start all workers
 func (p *Pool) Start() {
 	var worker *Worker
@@ -97,6 +115,15 @@ func (p *Pool) Size() uint32 {
 	return p.size
 }
 
+// QueueLen jobs waiting in the shared queue and the queues of the workers
+func (p *Pool) QueueLen() int {
+	n := len(p.jobQueue)
+	for _, worker := range p.workers {
+		n += len(worker.jobQueue)
+	}
+	return n
+}
+
 // Release rtStop all workers
 func (p *Pool) Release() {
 	for _, worker := range p.workers {
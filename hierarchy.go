@@ -0,0 +1,116 @@
+package ecs
+
+import "sync"
+
+type hierarchyOperate uint8
+
+const (
+	hierarchyOperateSetParent hierarchyOperate = iota
+	hierarchyOperateRemoveParent
+	hierarchyOperateDestroy
+)
+
+type hierarchyOp struct {
+	op     hierarchyOperate
+	child  Entity
+	parent Entity
+}
+
+// deferredOps a queue of operations recorded by systems in parallel and applied serially at the
+// next flush, shared by the hierarchy and the relations
+type deferredOps[T any] struct {
+	lock sync.Mutex
+	ops  []T
+}
+
+func (d *deferredOps[T]) push(op T) {
+	d.lock.Lock()
+	d.ops = append(d.ops, op)
+	d.lock.Unlock()
+}
+
+func (d *deferredOps[T]) take() []T {
+	d.lock.Lock()
+	ops := d.ops
+	d.ops = nil
+	d.lock.Unlock()
+	return ops
+}
+
+// hierarchy parent/child relationships of entities, changes are deferred like component operations
+// and take effect at the next flush, so the relationships are stable while systems are running.
+// The pairs are (child, parent), a child has one target at most.
+type hierarchy struct {
+	deferredOps[hierarchyOp]
+	pairs *relationSet
+}
+
+func newHierarchy() *hierarchy {
+	return &hierarchy{
+		pairs: newRelationSet(),
+	}
+}
+
+func (h *hierarchy) operate(op hierarchyOperate, child Entity, parent Entity) {
+	h.push(hierarchyOp{op: op, child: child, parent: parent})
+}
+
+func (h *hierarchy) getParent(child Entity) (Entity, bool) {
+	parents := h.pairs.targets[child]
+	if len(parents) == 0 {
+		return 0, false
+	}
+	return parents[0], true
+}
+
+func (h *hierarchy) getChildren(parent Entity) []Entity {
+	return h.pairs.sources[parent]
+}
+
+// flush apply the deferred operations in order, operations on destroyed entities and operations
+// creating a cycle are dropped
+func (h *hierarchy) flush(world *ecsWorld) {
+	for _, op := range h.take() {
+		switch op.op {
+		case hierarchyOperateSetParent:
+			if !world.entities.Exist(op.child) || !world.entities.Exist(op.parent) {
+				continue
+			}
+			if h.isAncestor(op.child, op.parent) {
+				world.logger.Errorf("set parent failed, entity %d is an ancestor of %d", op.child, op.parent)
+				continue
+			}
+			h.detach(op.child)
+			h.pairs.add(op.child, op.parent)
+		case hierarchyOperateRemoveParent:
+			h.detach(op.child)
+		case hierarchyOperateDestroy:
+			h.pairs.removeEntity(op.child)
+		}
+	}
+}
+
+func (h *hierarchy) isAncestor(ancestor Entity, entity Entity) bool {
+	for e, ok := entity, true; ok; e, ok = h.getParent(e) {
+		if e == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *hierarchy) detach(child Entity) {
+	if parent, ok := h.getParent(child); ok {
+		h.pairs.remove(child, parent)
+	}
+}
+
+// GetParent get the parent of an entity inside a system
+func GetParent(sys ISystem, child Entity) (Entity, bool) {
+	return sys.World().base().hierarchy.getParent(child)
+}
+
+// GetChildren get the children of an entity inside a system, the returned slice must not be modified
+func GetChildren(sys ISystem, parent Entity) []Entity {
+	return sys.World().base().hierarchy.getChildren(parent)
+}
@@ -0,0 +1,82 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __hierarchy_Test_C_1 struct {
+	Component[__hierarchy_Test_C_1]
+	Field1 int
+}
+
+type __hierarchy_Test_S_1 struct {
+	System[__hierarchy_Test_S_1]
+
+	root     Entity
+	children []Entity
+}
+
+func (s *__hierarchy_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__hierarchy_Test_C_1{})
+	return nil
+}
+
+func (s *__hierarchy_Test_S_1) Update(event Event) {
+	s.children = append(s.children[:0], GetChildren(s, s.root)...)
+}
+
+func TestSyncWorld_SetParent(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__hierarchy_Test_S_1](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__hierarchy_Test_S_1]())
+	sys := s.(*__hierarchy_Test_S_1)
+
+	root, c1, c2, g1 := world.NewEntity(), world.NewEntity(), world.NewEntity(), world.NewEntity()
+	for _, e := range []Entity{root, c1, c2, g1} {
+		world.Add(e, &__hierarchy_Test_C_1{})
+	}
+	sys.root = root
+	world.SetParent(c1, root)
+	world.SetParent(c2, root)
+	world.SetParent(g1, c1)
+	if len(world.hierarchy.getChildren(root)) != 0 {
+		t.Fatal("set parent must be deferred")
+	}
+	world.Update()
+	if len(sys.children) != 2 {
+		t.Fatalf("children of root, got %v", sys.children)
+	}
+	if parent, ok := world.hierarchy.getParent(g1); !ok || parent != c1 {
+		t.Fatal("parent of grandchild not set")
+	}
+
+	// cycle rejected
+	world.SetParent(root, g1)
+	world.RemoveParent(c2)
+	world.Update()
+	if _, ok := world.hierarchy.getParent(root); ok {
+		t.Fatal("cycle not rejected")
+	}
+	if len(sys.children) != 1 || sys.children[0] != c1 {
+		t.Fatalf("children of root after remove, got %v", sys.children)
+	}
+
+	// cascade destroy
+	world.DestroyEntity(root)
+	world.Update()
+	for _, e := range []Entity{root, c1, g1} {
+		if _, ok := world.getEntityInfo(e); ok {
+			t.Fatalf("entity %d not destroyed", e)
+		}
+	}
+	if _, ok := world.getEntityInfo(c2); !ok {
+		t.Fatal("detached child destroyed")
+	}
+	if len(world.hierarchy.pairs.targets) != 0 || len(world.hierarchy.pairs.sources) != 0 {
+		t.Fatal("hierarchy not cleaned up")
+	}
+	if world.getComponentSet(TypeOf[__hierarchy_Test_C_1]()).Len() != 1 {
+		t.Fatal("components of destroyed entities not removed")
+	}
+}
@@ -34,6 +34,7 @@ const (
 type StdLog struct {
 	logger *log.Logger
 	level  StdLogLevel
+	stack  bool
 }
 
 func NewStdLog(level ...StdLogLevel) *StdLog {
@@ -47,11 +48,22 @@ func NewStdLog(level ...StdLogLevel) *StdLog {
 	}
 }
 
+// SetStack append the stack trace to the error logs
+func (p *StdLog) SetStack(enable bool) {
+	p.stack = enable
+}
+
 func (p StdLog) Debug(v ...interface{}) {
+	if p.level > StdLogLevelDebug {
+		return
+	}
 	p.logger.Output(2, fmt.Sprintf("[DEBUG][%d] %s", goroutineID(), fmt.Sprint(v...)))
 }
 
 func (p StdLog) Debugf(format string, v ...interface{}) {
+	if p.level > StdLogLevelDebug {
+		return
+	}
 	p.logger.Output(2, fmt.Sprintf("[DEBUG][%d] %s", goroutineID(), fmt.Sprintf(format, v...)))
 }
 
@@ -73,23 +85,20 @@ func (p StdLog) Error(v ...interface{}) {
 	if p.level > StdLogLevelError {
 		return
 	}
-	buf := make([]byte, 1024)
-	for {
-		n := runtime.Stack(buf, false)
-		if n < len(buf) {
-			buf = buf[:n]
-			break
-		}
-		buf = make([]byte, 2*len(buf))
-	}
-	s := fmt.Sprint(append(v, "\n", string(buf))...)
-	p.logger.Output(2, s)
+	p.logger.Output(2, p.withStack(fmt.Sprint(v...)))
 }
 
 func (p StdLog) Errorf(format string, v ...interface{}) {
 	if p.level > StdLogLevelError {
 		return
 	}
+	p.logger.Output(2, p.withStack(fmt.Sprintf(format, v...)))
+}
+
+func (p StdLog) withStack(s string) string {
+	if !p.stack {
+		return s
+	}
 	buf := make([]byte, 1024)
 	for {
 		n := runtime.Stack(buf, false)
@@ -99,8 +108,36 @@ func (p StdLog) Errorf(format string, v ...interface{}) {
 		}
 		buf = make([]byte, 2*len(buf))
 	}
-	s := fmt.Sprint(fmt.Sprintf(format, v...), "\n", string(buf))
-	p.logger.Output(2, s)
+	return s + "\n" + string(buf)
+}
+
+func (p StdLog) enabled(level LogLevel) bool {
+	switch level {
+	case LogLevelDebug:
+		return p.level <= StdLogLevelDebug
+	case LogLevelInfo, LogLevelWarn:
+		return p.level <= StdLogLevelInfo
+	default:
+		return p.level <= StdLogLevelError
+	}
+}
+
+// output write a log of the FieldLogger adapter, calldepth as in log.Logger.Output, so that the
+// source location is the caller of the FieldLogger instead of the adapter
+func (p StdLog) output(calldepth int, level LogLevel, s string) {
+	if !p.enabled(level) {
+		return
+	}
+	switch level {
+	case LogLevelDebug:
+		s = fmt.Sprintf("[DEBUG][%d] %s", goroutineID(), s)
+	case LogLevelInfo:
+	case LogLevelWarn:
+		s = "[WARN] " + s
+	default:
+		s = p.withStack(s)
+	}
+	p.logger.Output(calldepth+1, s)
 }
 
 func (p StdLog) Fatal(v ...interface{}) {
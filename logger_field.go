@@ -0,0 +1,189 @@
+package ecs
+
+import (
+	"fmt"
+	"strings"
+)
+
+type LogLevel int8
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+var logLevelNames = map[LogLevel]string{
+	LogLevelDebug: "DEBUG",
+	LogLevelInfo:  "INFO",
+	LogLevelWarn:  "WARN",
+	LogLevelError: "ERROR",
+}
+
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LogLevel(%d)", l)
+}
+
+// FieldLogger structured logger, fields are key value pairs, e.g.
+//
+//	logger.Log(ecs.LogLevelInfo, "entity spawned", "entity", e, "prefab", "orc")
+type FieldLogger interface {
+	Enabled(level LogLevel) bool
+	Log(level LogLevel, msg string, fields ...any)
+	With(fields ...any) FieldLogger
+}
+
+// NewFieldLogger adapt a Logger, the fields are appended to the message as key=value, a nil
+// logger writes to the package Log at the time of logging
+func NewFieldLogger(logger Logger) FieldLogger {
+	return &legacyLogger{logger: logger}
+}
+
+// callerLogger a FieldLogger logging with the call depth of its caller, the loggers of the package
+// pass the depth through so that StdLog reports the source location of the original caller
+type callerLogger interface {
+	log(calldepth int, level LogLevel, msg string, fields ...any)
+}
+
+type legacyLogger struct {
+	logger Logger
+	fields []any
+}
+
+func (l *legacyLogger) get() Logger {
+	if l.logger == nil {
+		return Log
+	}
+	return l.logger
+}
+
+func (l *legacyLogger) Enabled(level LogLevel) bool {
+	switch logger := l.get().(type) {
+	case *StdLog:
+		return logger.enabled(level)
+	case StdLog:
+		return logger.enabled(level)
+	}
+	return true
+}
+
+func (l *legacyLogger) Log(level LogLevel, msg string, fields ...any) {
+	l.log(2, level, msg, fields...)
+}
+
+func (l *legacyLogger) log(calldepth int, level LogLevel, msg string, fields ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	line := formatLogLine(msg, l.fields, fields)
+	switch logger := l.get().(type) {
+	case *StdLog:
+		logger.output(calldepth+1, level, line)
+		return
+	case StdLog:
+		logger.output(calldepth+1, level, line)
+		return
+	}
+	switch level {
+	case LogLevelDebug:
+		l.get().Debug(line)
+	case LogLevelInfo:
+		l.get().Info(line)
+	case LogLevelWarn:
+		l.get().Info("[WARN] " + line)
+	default:
+		l.get().Error(line)
+	}
+}
+
+func (l *legacyLogger) With(fields ...any) FieldLogger {
+	return &legacyLogger{logger: l.logger, fields: joinFields(l.fields, fields)}
+}
+
+func joinFields(a []any, b []any) []any {
+	return append(a[:len(a):len(a)], b...)
+}
+
+func formatLogLine(msg string, fieldLists ...[]any) string {
+	b := &strings.Builder{}
+	b.WriteString(msg)
+	for _, fields := range fieldLists {
+		for i := 0; i < len(fields); i += 2 {
+			b.WriteByte(' ')
+			if i+1 < len(fields) {
+				fmt.Fprintf(b, "%v=%v", fields[i], fields[i+1])
+			} else {
+				fmt.Fprintf(b, "!BADKEY=%v", fields[i])
+			}
+		}
+	}
+	return b.String()
+}
+
+// worldLogger the logger of a world, the world id is attached, and the frame at the time of logging
+type worldLogger struct {
+	world  *ecsWorld
+	logger FieldLogger
+}
+
+func newWorldLogger(world *ecsWorld, logger FieldLogger) *worldLogger {
+	if logger == nil {
+		logger = NewFieldLogger(nil)
+	}
+	return &worldLogger{world: world, logger: logger.With("world", world.id)}
+}
+
+func (l *worldLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(level)
+}
+
+func (l *worldLogger) Log(level LogLevel, msg string, fields ...any) {
+	l.log(2, level, msg, fields...)
+}
+
+func (l *worldLogger) log(calldepth int, level LogLevel, msg string, fields ...any) {
+	if !l.logger.Enabled(level) {
+		return
+	}
+	fields = append([]any{"frame", l.world.frame}, fields...)
+	if logger, ok := l.logger.(callerLogger); ok {
+		logger.log(calldepth+1, level, msg, fields...)
+		return
+	}
+	l.logger.Log(level, msg, fields...)
+}
+
+func (l *worldLogger) With(fields ...any) FieldLogger {
+	return &worldLogger{world: l.world, logger: l.logger.With(fields...)}
+}
+
+func (l *worldLogger) Debugf(format string, v ...any) {
+	if l.logger.Enabled(LogLevelDebug) {
+		l.log(2, LogLevelDebug, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *worldLogger) Infof(format string, v ...any) {
+	if l.logger.Enabled(LogLevelInfo) {
+		l.log(2, LogLevelInfo, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *worldLogger) Errorf(format string, v ...any) {
+	if l.logger.Enabled(LogLevelError) {
+		l.log(2, LogLevelError, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *worldLogger) forSystem(sys ISystem) *worldLogger {
+	return &worldLogger{world: l.world, logger: l.logger.With("system", sys.Type().String())}
+}
+
+// Logger the logger of the world, the world id and frame are attached to the logs
+func (w *ecsWorld) Logger() FieldLogger {
+	return w.logger
+}
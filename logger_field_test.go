@@ -0,0 +1,124 @@
+package ecs
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type __logger_Test_Entry struct {
+	level  LogLevel
+	msg    string
+	fields map[any]any
+}
+
+type __logger_Test_Logger struct {
+	lock    *sync.Mutex
+	entries *[]__logger_Test_Entry
+	fields  []any
+}
+
+func (l *__logger_Test_Logger) Enabled(level LogLevel) bool {
+	return level >= LogLevelInfo
+}
+
+func (l *__logger_Test_Logger) Log(level LogLevel, msg string, fields ...any) {
+	entry := __logger_Test_Entry{level: level, msg: msg, fields: map[any]any{}}
+	all := joinFields(l.fields, fields)
+	for i := 0; i+1 < len(all); i += 2 {
+		entry.fields[all[i]] = all[i+1]
+	}
+	l.lock.Lock()
+	*l.entries = append(*l.entries, entry)
+	l.lock.Unlock()
+}
+
+func (l *__logger_Test_Logger) With(fields ...any) FieldLogger {
+	return &__logger_Test_Logger{lock: l.lock, entries: l.entries, fields: joinFields(l.fields, fields)}
+}
+
+type __logger_Test_S_1 struct {
+	System[__logger_Test_S_1]
+}
+
+func (s *__logger_Test_S_1) Update(event Event) {
+	s.Logger().Log(LogLevelDebug, "filtered")
+	s.Logger().Log(LogLevelInfo, "update", "key", 1)
+}
+
+func TestWorldLogger(t *testing.T) {
+	logger := &__logger_Test_Logger{lock: &sync.Mutex{}, entries: &[]__logger_Test_Entry{}}
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.Logger = logger
+	world := NewSyncWorld(config)
+	RegisterSystem[__logger_Test_S_1](world)
+	world.Startup()
+	world.Update()
+	world.Update()
+
+	var updates []__logger_Test_Entry
+	for _, e := range *logger.entries {
+		if e.fields["world"] != world.getID() {
+			t.Fatalf("world field, got %+v", e)
+		}
+		if e.msg == "filtered" {
+			t.Fatal("debug log not filtered")
+		}
+		if e.msg == "update" {
+			updates = append(updates, e)
+		}
+	}
+	if len(updates) != 2 || updates[1].fields["frame"] != uint64(1) || updates[1].fields["key"] != 1 ||
+		updates[1].fields["system"] != TypeOf[__logger_Test_S_1]().String() {
+		t.Fatalf("system logs, got %+v", updates)
+	}
+}
+
+func TestStdLog_level(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewStdLog(StdLogLevelInfo)
+	l.logger = log.New(buf, "", 0)
+	l.Debug("debug")
+	l.Info("info")
+	l.Error("error")
+	if got := buf.String(); got != "info\nerror\n" {
+		t.Fatalf("output, got %q", got)
+	}
+
+	fl := NewFieldLogger(l).With("world", 1)
+	if fl.Enabled(LogLevelDebug) {
+		t.Fatal("debug enabled")
+	}
+	buf.Reset()
+	fl.Log(LogLevelWarn, "slow", "frame", 2)
+	if got := buf.String(); !strings.Contains(got, "[WARN] slow world=1 frame=2") {
+		t.Fatalf("fields, got %q", got)
+	}
+}
+
+func TestStdLog_callerLocation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewStdLog(StdLogLevelDebug)
+	l.logger = log.New(buf, "", log.Lshortfile)
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.Logger = NewFieldLogger(l)
+	world := NewSyncWorld(config)
+
+	NewFieldLogger(l).Log(LogLevelInfo, "adapter")
+	world.Logger().Log(LogLevelWarn, "world")
+	world.Logger().With("key", 1).Log(LogLevelDebug, "with")
+	world.logger.Errorf("formatted %d", 1)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("lines, got %q", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "logger_field_test.go:") {
+			t.Fatalf("source location, got %q", line)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build go1.21
+
+package ecs
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger adapt a log/slog logger, LogLevelWarn maps to slog.LevelWarn and so on
+func NewSlogLogger(logger *slog.Logger) FieldLogger {
+	return &slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func (l *slogLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(context.Background(), slogLevel(level))
+}
+
+func (l *slogLogger) Log(level LogLevel, msg string, fields ...any) {
+	l.logger.Log(context.Background(), slogLevel(level), msg, fields...)
+}
+
+func (l *slogLogger) With(fields ...any) FieldLogger {
+	return &slogLogger{logger: l.logger.With(fields...)}
+}
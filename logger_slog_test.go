@@ -0,0 +1,23 @@
+//go:build go1.21
+
+package ecs
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	logger = logger.With("world", 1)
+	if logger.Enabled(LogLevelDebug) {
+		t.Fatal("debug enabled")
+	}
+	logger.Log(LogLevelWarn, "slow", "frame", 2)
+	if got := buf.String(); !strings.Contains(got, "level=WARN msg=slow world=1 frame=2") {
+		t.Fatalf("output, got %q", got)
+	}
+}
@@ -1,11 +1,21 @@
 package ecs
 
-import "time"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
 
 type Metrics struct {
-	enable  bool
-	isPrint bool
-	m       map[string]*MetricReporter
+	enable        bool
+	isPrint       bool
+	printInterval time.Duration
+	lastPrint     time.Time
+	lock          sync.Mutex
+	m             map[string]*MetricReporter
+	world         int64
+	logger        FieldLogger
+	series        *metricSeries
 }
 
 func (m *Metrics) NewReporter(name string) *MetricReporter {
@@ -21,16 +31,44 @@ func (m *Metrics) Print() {
 	if !m.enable {
 		return
 	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	for _, reporter := range m.m {
 		reporter.Print()
 	}
 }
 
+// getLogger the logger of the world, the package Log for metrics not owned by a world
+func (m *Metrics) getLogger() FieldLogger {
+	if m.logger == nil {
+		return NewFieldLogger(nil)
+	}
+	return m.logger
+}
+
+// printFrame print the phases of a frame, at most once per print interval instead of every frame
+func (m *Metrics) printFrame(reporter *MetricReporter) {
+	if !m.enable || !m.isPrint {
+		return
+	}
+	interval := m.printInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	now := time.Now()
+	if now.Sub(m.lastPrint) < interval {
+		return
+	}
+	m.lastPrint = now
+	reporter.Print()
+}
+
 func NewMetrics(enable bool, print bool) *Metrics {
 	return &Metrics{
 		enable:  enable,
 		isPrint: print,
 		m:       make(map[string]*MetricReporter),
+		series:  newMetricSeries(),
 	}
 }
 
@@ -76,7 +114,9 @@ func (m *MetricReporter) Stop() {
 	now := time.Now()
 	m.elapsedTotal = now.Sub(m.start)
 	if m.metrics != nil {
+		m.metrics.lock.Lock()
 		m.metrics.m[m.name] = m
+		m.metrics.lock.Unlock()
 	}
 }
 
@@ -87,8 +127,9 @@ func (m *MetricReporter) Print(force ...bool) {
 			return
 		}
 	}
-	Log.Infof("%s: cost: %+v\n", m.name, m.elapsedTotal)
+	logger := m.metrics.getLogger()
+	logger.Log(LogLevelInfo, fmt.Sprintf("%s: cost: %+v", m.name, m.elapsedTotal))
 	for _, r := range m.sampleElapsed {
-		Log.Infof("    ├─%20s: %+v\n", r.name, r.elapsed)
+		logger.Log(LogLevelInfo, fmt.Sprintf("    ├─%20s: %+v", r.name, r.elapsed))
 	}
 }
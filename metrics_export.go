@@ -0,0 +1,297 @@
+package ecs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// buckets of the duration histograms, in seconds
+var metricDurationBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// metricHistogram observed with atomic counters, the systems of a batch observe their own
+// histograms in parallel without a shared lock
+type metricHistogram struct {
+	counts []uint64
+	sum    uint64 // nanoseconds
+	count  uint64
+}
+
+func newMetricHistogram() *metricHistogram {
+	return &metricHistogram{counts: make([]uint64, len(metricDurationBuckets))}
+}
+
+func (h *metricHistogram) observe(d time.Duration) {
+	v := d.Seconds()
+	for i, le := range metricDurationBuckets {
+		if v <= le {
+			atomic.AddUint64(&h.counts[i], 1)
+		}
+	}
+	atomic.AddUint64(&h.sum, uint64(d))
+	atomic.AddUint64(&h.count, 1)
+}
+
+type metricSystemKey struct {
+	system string
+	stage  Stage
+}
+
+// metricSeries the values exported by the metrics handler, guarded by the lock of Metrics except
+// the histograms of the systems, which are observed atomically
+type metricSeries struct {
+	frames     uint64
+	phases     map[string]*metricHistogram
+	systems    map[metricSystemKey]*metricHistogram
+	entities   int
+	components map[string]int
+	poolQueue  int
+}
+
+func newMetricSeries() *metricSeries {
+	return &metricSeries{
+		phases:     map[string]*metricHistogram{},
+		systems:    map[metricSystemKey]*metricHistogram{},
+		components: map[string]int{},
+	}
+}
+
+// observeFrame record the phases sampled by the reporter of a frame
+func (m *Metrics) observeFrame(reporter *MetricReporter) {
+	if !m.enable {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.series.frames++
+	for _, step := range reporter.sampleElapsed {
+		h, ok := m.series.phases[step.name]
+		if !ok {
+			h = newMetricHistogram()
+			m.series.phases[step.name] = h
+		}
+		h.observe(step.elapsed)
+	}
+}
+
+// observeSystem record an execution of a system, called by the workers in parallel stages. The
+// histogram is cached by the timing of the system, the lock of Metrics is only taken the first
+// time the system runs in a stage
+func (m *Metrics) observeSystem(sys ISystem, stage Stage, d time.Duration) {
+	if !m.enable {
+		return
+	}
+	sys.getTiming().histogram(stage, func() *metricHistogram {
+		return m.systemHistogram(sys, stage)
+	}).observe(d)
+}
+
+func (m *Metrics) systemHistogram(sys ISystem, stage Stage) *metricHistogram {
+	key := metricSystemKey{system: sys.Type().String(), stage: stage}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	h, ok := m.series.systems[key]
+	if !ok {
+		h = newMetricHistogram()
+		m.series.systems[key] = h
+	}
+	return h
+}
+
+// collectWorld sample the gauges at the end of a frame, the handler does not touch the world
+func (m *Metrics) collectWorld(w *ecsWorld) {
+	if !m.enable {
+		return
+	}
+	entities := w.entities.Len()
+	components := map[string]int{}
+	w.components.getCollections().Range(func(set *IComponentSet) bool {
+		components[(*set).GetElementMeta().typ.String()] = (*set).Len()
+		return true
+	})
+	poolQueue := w.workPool.QueueLen()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.series.entities = entities
+	m.series.components = components
+	m.series.poolQueue = poolQueue
+}
+
+// MetricsHandler serve the metrics of the worlds in the OpenMetrics text format, each series is
+// labeled with the world id
+func MetricsHandler(worlds ...IWorld) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		metrics := make([]*Metrics, 0, len(worlds))
+		for _, world := range worlds {
+			metrics = append(metrics, world.getMetrics())
+		}
+		if err := WriteOpenMetrics(rw, metrics...); err != nil {
+			for _, m := range metrics {
+				m.getLogger().Log(LogLevelError, "write metrics failed", "error", err)
+			}
+		}
+	})
+}
+
+type metricLabel struct {
+	name  string
+	value string
+}
+
+type metricWriter struct {
+	w *bufio.Writer
+}
+
+func (mw *metricWriter) family(name string, typ string, help string) {
+	fmt.Fprintf(mw.w, "# TYPE %s %s\n# HELP %s %s\n", name, typ, name, help)
+}
+
+func (mw *metricWriter) sample(name string, labels []metricLabel, value string) {
+	mw.w.WriteString(name)
+	if len(labels) > 0 {
+		mw.w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				mw.w.WriteByte(',')
+			}
+			mw.w.WriteString(l.name)
+			mw.w.WriteString(`="`)
+			mw.w.WriteString(metricLabelEscaper.Replace(l.value))
+			mw.w.WriteByte('"')
+		}
+		mw.w.WriteByte('}')
+	}
+	mw.w.WriteByte(' ')
+	mw.w.WriteString(value)
+	mw.w.WriteByte('\n')
+}
+
+func (mw *metricWriter) histogram(name string, labels []metricLabel, h *metricHistogram) {
+	bucket := append(labels[:len(labels):len(labels)], metricLabel{name: "le"})
+	for i, le := range metricDurationBuckets {
+		bucket[len(labels)].value = formatMetricFloat(le)
+		mw.sample(name+"_bucket", bucket, strconv.FormatUint(h.counts[i], 10))
+	}
+	bucket[len(labels)].value = "+Inf"
+	mw.sample(name+"_bucket", bucket, strconv.FormatUint(h.count, 10))
+	mw.sample(name+"_sum", labels, formatMetricFloat(time.Duration(h.sum).Seconds()))
+	mw.sample(name+"_count", labels, strconv.FormatUint(h.count, 10))
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatMetricFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// WriteOpenMetrics write the metrics in the OpenMetrics text format
+func WriteOpenMetrics(w io.Writer, metrics ...*Metrics) error {
+	mw := &metricWriter{w: bufio.NewWriter(w)}
+	series := make([]*metricSeries, len(metrics))
+	worlds := make([]metricLabel, len(metrics))
+	// copy the series, so that the frames are not blocked by a slow scrape
+	for i, m := range metrics {
+		m.lock.Lock()
+		series[i] = m.series.clone()
+		m.lock.Unlock()
+		worlds[i] = metricLabel{name: "world", value: strconv.FormatInt(m.world, 10)}
+	}
+
+	mw.family("ecs_frames", "counter", "Frames updated by the world.")
+	for i, s := range series {
+		mw.sample("ecs_frames_total", worlds[i:i+1], strconv.FormatUint(s.frames, 10))
+	}
+
+	mw.family("ecs_frame_phase_seconds", "histogram", "Duration of the phases of a frame.")
+	for i, s := range series {
+		phases := make([]string, 0, len(s.phases))
+		for phase := range s.phases {
+			phases = append(phases, phase)
+		}
+		sort.Strings(phases)
+		for _, phase := range phases {
+			mw.histogram("ecs_frame_phase_seconds", []metricLabel{worlds[i], {"phase", phase}}, s.phases[phase])
+		}
+	}
+
+	mw.family("ecs_system_seconds", "histogram", "Execution time of a system in a stage.")
+	for i, s := range series {
+		keys := make([]metricSystemKey, 0, len(s.systems))
+		for key := range s.systems {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(a, b int) bool {
+			if keys[a].system != keys[b].system {
+				return keys[a].system < keys[b].system
+			}
+			return keys[a].stage < keys[b].stage
+		})
+		for _, key := range keys {
+			labels := []metricLabel{worlds[i], {"system", key.system}, {"stage", key.stage.String()}}
+			mw.histogram("ecs_system_seconds", labels, s.systems[key])
+		}
+	}
+
+	mw.family("ecs_entities", "gauge", "Alive entities at the end of the last frame.")
+	for i, s := range series {
+		mw.sample("ecs_entities", worlds[i:i+1], strconv.Itoa(s.entities))
+	}
+
+	mw.family("ecs_component_set_size", "gauge", "Components in a component set at the end of the last frame.")
+	for i, s := range series {
+		types := make([]string, 0, len(s.components))
+		for typ := range s.components {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		for _, typ := range types {
+			mw.sample("ecs_component_set_size", []metricLabel{worlds[i], {"component", typ}}, strconv.Itoa(s.components[typ]))
+		}
+	}
+
+	mw.family("ecs_pool_queue_depth", "gauge", "Jobs waiting in the worker pool at the end of the last frame.")
+	for i, s := range series {
+		mw.sample("ecs_pool_queue_depth", worlds[i:i+1], strconv.Itoa(s.poolQueue))
+	}
+
+	mw.w.WriteString("# EOF\n")
+	return mw.w.Flush()
+}
+
+func (s *metricSeries) clone() *metricSeries {
+	c := &metricSeries{
+		frames:     s.frames,
+		phases:     make(map[string]*metricHistogram, len(s.phases)),
+		systems:    make(map[metricSystemKey]*metricHistogram, len(s.systems)),
+		entities:   s.entities,
+		components: s.components,
+		poolQueue:  s.poolQueue,
+	}
+	for k, h := range s.phases {
+		c.phases[k] = h.clone()
+	}
+	for k, h := range s.systems {
+		c.systems[k] = h.clone()
+	}
+	return c
+}
+
+func (h *metricHistogram) clone() *metricHistogram {
+	c := &metricHistogram{
+		counts: make([]uint64, len(h.counts)),
+		sum:    atomic.LoadUint64(&h.sum),
+		count:  atomic.LoadUint64(&h.count),
+	}
+	for i := range h.counts {
+		c.counts[i] = atomic.LoadUint64(&h.counts[i])
+	}
+	return c
+}
@@ -0,0 +1,99 @@
+package ecs
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type __metricsExport_Test_C_1 struct {
+	Component[__metricsExport_Test_C_1]
+}
+
+type __metricsExport_Test_S_1 struct {
+	System[__metricsExport_Test_S_1]
+}
+
+func (s *__metricsExport_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__metricsExport_Test_C_1{})
+	return nil
+}
+
+func (s *__metricsExport_Test_S_1) Update(event Event) {}
+
+func TestMetricsHandler(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__metricsExport_Test_S_1](world)
+	world.Startup()
+
+	for i := 0; i < 3; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__metricsExport_Test_C_1{})
+	}
+	world.Update()
+	world.Update()
+
+	server := httptest.NewServer(MetricsHandler(world))
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(data)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/openmetrics-text") {
+		t.Fatalf("content type, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	id := strconv.FormatInt(world.getID(), 10)
+	for _, want := range []string{
+		"ecs_frames_total{world=\"" + id + "\"} 2\n",
+		"ecs_frame_phase_seconds_count{world=\"" + id + "\",phase=\"system execute\"} 2\n",
+		"ecs_system_seconds_count{world=\"" + id + "\",system=\"ecs.__metricsExport_Test_S_1\",stage=\"StageUpdate\"} 2\n",
+		"ecs_entities{world=\"" + id + "\"} 3\n",
+		"ecs_component_set_size{world=\"" + id + "\",component=\"ecs.__metricsExport_Test_C_1\"} 3\n",
+		"ecs_pool_queue_depth{world=\"" + id + "\"} 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in\n%s", want, body)
+		}
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatal("missing EOF")
+	}
+}
+
+func TestMetrics_printInterval(t *testing.T) {
+	logger := &__logger_Test_Logger{lock: &sync.Mutex{}, entries: &[]__logger_Test_Entry{}}
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.IsMetricsPrint = true
+	config.MetricsPrintInterval = time.Hour
+	config.Logger = logger
+	world := NewSyncWorld(config)
+	RegisterSystem[__metricsExport_Test_S_1](world)
+	world.Startup()
+	for i := 0; i < 10; i++ {
+		world.Update()
+	}
+
+	printed := 0
+	for _, e := range *logger.entries {
+		if strings.HasPrefix(e.msg, "system_flow_run: cost") {
+			printed++
+		}
+	}
+	if printed != 1 {
+		t.Fatalf("frame phases printed %d times in an interval", printed)
+	}
+}
@@ -74,21 +74,21 @@ func (o *optimizer) collect() {
 	o.lastCollectConsumption = time.Since(start)
 }
 
+// optimize the costs are reported through the Metrics of the world only, it may run every idle
+// frame of an AsyncWorld
 func (o *optimizer) optimize(IdleTime time.Duration, force bool) {
-	Log.Infof("start optimize, rest time: %v", IdleTime)
+	reporter := o.world.metrics.NewReporter("optimizer")
+	reporter.Start()
+	defer reporter.Stop()
+
 	o.startTime = time.Now()
 	o.lastSample = time.Now()
 	o.expireTime = o.startTime.Add(IdleTime)
 
 	o.collect()
-	elapsed := o.elapsedStep()
-	Log.Infof("collect step 1: %v", elapsed)
-
-	o.memTidy(force)
+	reporter.Sample("collect")
 
-	rest := o.expire()
-	total := time.Now().Sub(o.startTime)
-	Log.Infof("end optimize, rest time: %v, total: %v", rest, total)
+	o.memTidy(force, reporter)
 }
 
 func (o *optimizer) expire() time.Duration {
@@ -106,56 +106,80 @@ func (o *optimizer) elapsedStep() time.Duration {
 	return r
 }
 
-func (o *optimizer) memTidy(force bool) {
-	//seq := uint32(0)
-	//m := map[interface{}][]*EntityInfo{}
-	//o.world.entities.foreach(func(entity Entity, info *EntityInfo) bool {
-	//	c := info.getCompound().Type()
-	//	_, ok := m[c]
-	//	if !ok {
-	//		m[c] = []*EntityInfo{}
-	//	}
-	//	m[c] = append(m[c], info)
-	//	return true
-	//})
-	//
-	//elapsed := o.elapsedStep()
-	//rest := o.expire()
-	//Log.Infof("memTidy step 1: %v, expire: %v", elapsed, rest)
-	//if !force && rest < time.Millisecond {
-	//	return
-	//}
-	//
-	//for _, infos := range m {
-	//	for _, info := range infos {
-	//		seq++
-	//		for _, component := range info.components {
-	//			component.setSeq(seq)
-	//			c := o.world.components.getComponentSet(component.Type()).GetByEntity(int64(component.Owner().Entity()))
-	//			verify := c.(IComponent)
-	//			println(component.debugAddress(), verify.debugAddress())
-	//			if verify.getSeq() != component.getSeq() {
-	//				Log.Errorf("component seq error, %v, %v", verify.getSeq(), component.getSeq())
-	//			}
-	//		}
-	//	}
-	//}
-	//
-	//elapsed = o.elapsedStep()
-	//rest = o.expire()
-	//Log.Infof("memTidy step 2: %v, expire: %v", elapsed, rest)
-	//if !force && rest < time.Millisecond {
-	//	return
-	//}
-	//
-	//for _, collection := range o.world.components.getCollections() {
-	//	collection.Sort()
-	//	if !force && o.expire() < time.Millisecond {
-	//		break
-	//	}
-	//}
-	//
-	//elapsed = o.elapsedStep()
-	//rest = o.expire()
-	//Log.Infof("memTidy step 3: %v, expire: %v", elapsed, rest)
+// memTidy rank entities by the most used shapes and sort the component sets of the shapes by
+// the rank, so the siblings of a shape are at the same position in their sets
+func (o *optimizer) memTidy(force bool, reporter *MetricReporter) {
+	if o.world.archetypes != nil {
+		// the layout is maintained by the archetype storage
+		return
+	}
+
+	collections := o.world.components.getCollections()
+	getSet := func(it uint16) IComponentSet {
+		set := collections.Get(it)
+		if set == nil {
+			return nil
+		}
+		return *set
+	}
+
+	seq := uint32(0)
+	rank := map[Entity]uint32{}
+	var sets []IComponentSet
+	added := map[uint16]bool{}
+	for _, info := range o.shapeInfos {
+		if info.eNum == 0 {
+			continue
+		}
+		var guide IComponentSet
+		var subSets []IComponentSet
+		for _, it := range info.shapes[0].getSubTypes() {
+			set := getSet(it)
+			if set == nil || set.Len() == 0 {
+				guide = nil
+				break
+			}
+			if guide == nil || set.Len() < guide.Len() {
+				guide = set
+			}
+			subSets = append(subSets, set)
+			if !added[it] {
+				added[it] = true
+				sets = append(sets, set)
+			}
+		}
+		if guide == nil {
+			continue
+		}
+	next:
+		for i := 0; i < guide.Len(); i++ {
+			entity := (*EmptyComponent)(guide.getPointerByIndex(int64(i))).Owner()
+			if _, ok := rank[entity]; ok {
+				continue
+			}
+			for _, set := range subSets {
+				if set.getPointerByEntity(entity) == nil {
+					continue next
+				}
+			}
+			seq++
+			rank[entity] = seq
+		}
+		if !force && o.expire() < time.Millisecond {
+			break
+		}
+	}
+	reporter.Sample("rank entities")
+
+	for _, set := range sets {
+		for i := 0; i < set.Len(); i++ {
+			ec := (*EmptyComponent)(set.getPointerByIndex(int64(i)))
+			ec.seq = rank[ec.owner]
+		}
+		set.Sort()
+		reporter.Sample("sort " + set.GetElementMeta().typ.Name())
+		if !force && o.expire() < time.Millisecond {
+			break
+		}
+	}
 }
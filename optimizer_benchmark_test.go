@@ -21,27 +21,31 @@ type __optimizer_Bench_C_2 struct {
 	Test2 int
 }
 
+type __optimizer_Bench_Shape_1 struct {
+	c1 *__optimizer_Bench_C_1
+	c2 *__optimizer_Bench_C_2
+}
+
 type __optimizer_Bench_S_1 struct {
 	System[__optimizer_Bench_S_1]
+	shape *Shape[__optimizer_Bench_Shape_1]
 }
 
-func (t *__optimizer_Bench_S_1) Init(si SystemInitConstraint) {
+func (t *__optimizer_Bench_S_1) Init(si SystemInitConstraint) error {
 	t.SetRequirements(si, &__optimizer_Bench_C_1{}, &__optimizer_Bench_C_2{})
+	t.shape = NewShape[__optimizer_Bench_Shape_1](si)
+	return nil
 }
 
 func (t *__optimizer_Bench_S_1) Update(event Event) {
-	iter := GetComponentAll[__optimizer_Bench_C_1](t)
-	for c := iter.Begin(); !iter.End(); c = iter.Next() {
-		c2 := GetRelated[__optimizer_Bench_C_2](t, c.owner)
-		if c2 == nil {
-			continue
-		}
+	iter := t.shape.Get()
+	for s := iter.Begin(); !iter.End(); s = iter.Next() {
 		for i := 0; i < testOptimizerDummyMaxFor; i++ {
-			c.Test1 += i
+			s.c1.Test1 += i
 		}
 
 		for i := 0; i < testOptimizerDummyMaxFor; i++ {
-			c2.Test2 += i
+			s.c2.Test2 += i
 		}
 	}
 }
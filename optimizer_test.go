@@ -0,0 +1,120 @@
+package ecs
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSyncWorld_Optimize(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	RegisterSystem[__optimizer_Bench_S_1](world)
+	world.Startup()
+
+	var entities []Entity
+	for i := 0; i < 1000; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__optimizer_Bench_C_1{Test1: i})
+		entities = append(entities, e)
+	}
+	rand.Shuffle(len(entities), func(i, j int) { entities[i], entities[j] = entities[j], entities[i] })
+	// every third entity has no C_2
+	for i, e := range entities {
+		if i%3 != 0 {
+			world.Add(e, &__optimizer_Bench_C_2{Test2: i})
+		}
+	}
+	world.Update()
+
+	world.Optimize(time.Second, false)
+
+	set1 := world.getComponentSet(TypeOf[__optimizer_Bench_C_1]())
+	set2 := world.getComponentSet(TypeOf[__optimizer_Bench_C_2]())
+	for i := 0; i < set2.Len(); i++ {
+		e1 := (*EmptyComponent)(set1.getPointerByIndex(int64(i))).Owner()
+		e2 := (*EmptyComponent)(set2.getPointerByIndex(int64(i))).Owner()
+		if e1 != e2 {
+			t.Fatalf("index %d not aligned, %d != %d", i, e1, e2)
+		}
+	}
+	if reporter, ok := world.metrics.m["optimizer"]; !ok || len(reporter.sampleElapsed) == 0 {
+		t.Fatal("optimizer costs not reported through metrics")
+	}
+	for _, e := range entities {
+		c := set1.getPointerByEntity(e)
+		if c == nil || (*EmptyComponent)(c).Owner() != e {
+			t.Fatalf("entity %d index broken", e)
+		}
+	}
+
+	// world still works after tidy
+	world.Remove(entities[1], &__optimizer_Bench_C_1{})
+	world.Update()
+	if set1.getPointerByEntity(entities[1]) != nil || set1.Len() != 999 {
+		t.Fatal("remove after optimize failed")
+	}
+}
+
+func TestAsyncWorld_IdleOptimize(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.FrameInterval = time.Millisecond * 20
+	config.IdleOptimize = time.Millisecond
+	world := NewAsyncWorld(config)
+	RegisterSystem[__optimizer_Bench_S_1](world)
+	world.Startup()
+	defer world.Stop()
+
+	// Sync and wait, Wait holds the queue lock while waiting
+	wait := func(fn func(g SyncWrapper) error) {
+		done := make(chan struct{})
+		world.Sync(func(g SyncWrapper) error {
+			defer close(done)
+			return fn(g)
+		})
+		<-done
+	}
+	wait(func(g SyncWrapper) error {
+		var entities []Entity
+		for i := 0; i < 1000; i++ {
+			e := g.NewEntity()
+			g.Add(e, &__optimizer_Bench_C_1{Test1: i})
+			entities = append(entities, e)
+		}
+		rand.Shuffle(len(entities), func(i, j int) { entities[i], entities[j] = entities[j], entities[i] })
+		for i, e := range entities {
+			if i%3 != 0 {
+				g.Add(e, &__optimizer_Bench_C_2{Test2: i})
+			}
+		}
+		return nil
+	})
+
+	// a few frames for the components to be added and the idle time to tidy them
+	aligned := false
+	for i := 0; i < 50 && !aligned; i++ {
+		time.Sleep(config.FrameInterval)
+		wait(func(g SyncWrapper) error {
+			set1 := world.getComponentSet(TypeOf[__optimizer_Bench_C_1]())
+			set2 := world.getComponentSet(TypeOf[__optimizer_Bench_C_2]())
+			if set2.Len() == 0 {
+				return nil
+			}
+			aligned = true
+			for i := 0; i < set2.Len(); i++ {
+				e1 := (*EmptyComponent)(set1.getPointerByIndex(int64(i))).Owner()
+				e2 := (*EmptyComponent)(set2.getPointerByIndex(int64(i))).Owner()
+				if e1 != e2 {
+					aligned = false
+					break
+				}
+			}
+			return nil
+		})
+	}
+	if !aligned {
+		t.Fatal("component sets not tidied in the idle time")
+	}
+}
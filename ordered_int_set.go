@@ -49,6 +49,15 @@ func (c *OrderedIntSet[T]) Exist(it T) bool {
 	return c.Find(it) != -1
 }
 
+func (c *OrderedIntSet[T]) ExistAny(its []T) bool {
+	for _, it := range its {
+		if c.Find(it) != -1 {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *OrderedIntSet[T]) IsSubSet(subSet OrderedIntSet[T]) bool {
 	offset := 0
 	length := len(*c)
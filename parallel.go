@@ -0,0 +1,145 @@
+package ecs
+
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	parallelChunkTarget = 100 * time.Microsecond // target duration of a chunk
+	parallelMinChunk    = 64
+)
+
+// rangeIterator an iterator that can be split into ranges iterated in parallel
+type rangeIterator[T any] interface {
+	rangeLen() int
+	subRange(begin int, end int) Iterator[T]
+}
+
+// ParallelForEach iterate a component iterator from GetComponentAll in parallel on the worker pool
+// of the world, e.g.
+//
+//	ecs.ParallelForEach(s, ecs.GetComponentAll[Position](s), func(p *Position) {
+//		p.X += 1
+//	})
+//
+// the iterator is split into chunks sized by the measured cost per element, fn is called concurrently
+// and must only touch the element it is given. Returns after all elements are visited
+func ParallelForEach[T any](sys ISystem, iter Iterator[T], fn func(v *T)) {
+	ri, ok := iter.(rangeIterator[T])
+	if !ok {
+		for v := iter.Begin(); !iter.End(); v = iter.Next() {
+			fn(v)
+		}
+		return
+	}
+	n := ri.rangeLen()
+	if n <= 0 {
+		return
+	}
+
+	pool := sys.World().base().workPool
+	costs := sys.getParallelCosts()
+	typ := TypeOf[T]()
+	cost := costs[typ]
+	chunk := parallelChunkSize(n, int(pool.Size()), cost)
+	start := time.Now()
+	if chunk >= n {
+		for v := iter.Begin(); !iter.End(); v = iter.Next() {
+			fn(v)
+		}
+		costs[typ] = parallelUpdateCost(cost, time.Since(start), n)
+		return
+	}
+
+	chunks := (n + chunk - 1) / chunk
+	next := int64(-1)
+	elapsed := int64(0)
+	wg := &sync.WaitGroup{}
+	wg.Add(chunks)
+	// the caller takes chunks as well, helpers started after all chunks are taken return at once,
+	// so waiting never depends on a job queued behind a busy worker
+	// a panic in a chunk is raised again in the caller after all chunks are done
+	var panicked atomic.Value
+	process := func(c int) {
+		defer func() {
+			if r := recover(); r != nil {
+				panicked.CompareAndSwap(nil, &workerPanic{value: r, stack: debug.Stack()})
+			}
+			wg.Done()
+		}()
+		begin := c * chunk
+		end := begin + chunk
+		if end > n {
+			end = n
+		}
+		ts := time.Now()
+		sub := ri.subRange(begin, end)
+		for v := sub.Begin(); !sub.End(); v = sub.Next() {
+			fn(v)
+		}
+		atomic.AddInt64(&elapsed, int64(time.Since(ts)))
+	}
+	run := func() {
+		for {
+			c := int(atomic.AddInt64(&next, 1))
+			if c >= chunks {
+				return
+			}
+			process(c)
+		}
+	}
+	helpers := int(pool.Size())
+	if helpers > chunks {
+		helpers = chunks
+	}
+	for i := 0; i < helpers-1; i++ {
+		if !pool.TryAdd(run) {
+			break
+		}
+	}
+	run()
+	wg.Wait()
+	if wp := panicked.Load(); wp != nil {
+		panic(wp)
+	}
+	costs[typ] = parallelUpdateCost(cost, time.Duration(atomic.LoadInt64(&elapsed)), n)
+}
+
+// ParallelForEachShape iterate the shape in parallel like ParallelForEach
+func ParallelForEachShape[T any](shape *Shape[T], fn func(v *T)) {
+	ParallelForEach[T](shape.sys, Iterator[T](shape.Get()), fn)
+}
+
+// parallelChunkSize chunks of about parallelChunkTarget by the measured cost per element, without
+// a measurement every worker gets a few chunks. Returns n if the work is too small to split
+func parallelChunkSize(n int, workers int, cost float64) int {
+	if workers <= 1 {
+		return n
+	}
+	chunk := (n + workers*4 - 1) / (workers * 4)
+	if cost > 0 {
+		if cost*float64(n) < float64(parallelChunkTarget) {
+			return n
+		}
+		chunk = int(float64(parallelChunkTarget) / cost)
+		if most := (n + workers - 1) / workers; chunk > most {
+			chunk = most
+		}
+	}
+	if chunk < parallelMinChunk {
+		chunk = parallelMinChunk
+	}
+	return chunk
+}
+
+// parallelUpdateCost moving average of the cost per element in nanoseconds
+func parallelUpdateCost(cost float64, elapsed time.Duration, n int) float64 {
+	sample := float64(elapsed) / float64(n)
+	if cost == 0 {
+		return sample
+	}
+	return (cost*3 + sample) / 4
+}
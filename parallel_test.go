@@ -0,0 +1,109 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __parallel_Test_C_1 struct {
+	Component[__parallel_Test_C_1]
+	Field1 int
+}
+
+type __parallel_Test_C_2 struct {
+	Component[__parallel_Test_C_2]
+	Field1 int
+}
+
+type __parallel_Test_Shape_1 struct {
+	c1 *__parallel_Test_C_1
+	c2 *__parallel_Test_C_2
+}
+
+type __parallel_Test_S_1 struct {
+	System[__parallel_Test_S_1]
+
+	shape *Shape[__parallel_Test_Shape_1]
+}
+
+func (s *__parallel_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__parallel_Test_C_1{}, &ReadOnly[__parallel_Test_C_2]{})
+	s.shape = NewShape[__parallel_Test_Shape_1](si)
+	return nil
+}
+
+func (s *__parallel_Test_S_1) Update(event Event) {
+	ParallelForEachShape(s.shape, func(v *__parallel_Test_Shape_1) {
+		v.c1.Field1 += v.c2.Field1
+	})
+	ParallelForEach(s, GetComponentAll[__parallel_Test_C_1](s), func(c *__parallel_Test_C_1) {
+		c.Field1++
+	})
+}
+
+func testParallelForEach(t *testing.T, archetype bool) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.ArchetypeStorage = archetype
+	world := NewSyncWorld(config)
+	RegisterSystem[__parallel_Test_S_1](world)
+	world.Startup()
+
+	const count = 20000
+	for i := 0; i < count; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__parallel_Test_C_1{Field1: i})
+		// every third entity is not matched by the shape
+		if i%3 != 0 {
+			world.Add(e, &__parallel_Test_C_2{Field1: 1})
+		}
+	}
+	world.Update()
+	world.Update()
+
+	set := world.getComponentSet(TypeOf[__parallel_Test_C_1]()).(*ComponentSet[__parallel_Test_C_1])
+	if set.Len() != count {
+		t.Fatalf("components, got %d", set.Len())
+	}
+	set2 := world.getComponentSet(TypeOf[__parallel_Test_C_2]()).(*ComponentSet[__parallel_Test_C_2])
+	for i := 0; i < set.Len(); i++ {
+		c := (*__parallel_Test_C_1)(set.getPointerByIndex(int64(i)))
+		want := 2
+		if set2.getByEntity(c.Owner()) != nil {
+			want = 4
+		}
+		origin := int(c.Owner().ToRealID().index) - 1
+		if c.Field1-origin != want {
+			t.Fatalf("entity %d, want %d got %d", c.Owner(), origin+want, c.Field1)
+		}
+	}
+
+	s, _ := world.getSystem(TypeOf[__parallel_Test_S_1]())
+	if cost := s.getParallelCosts()[TypeOf[__parallel_Test_Shape_1]()]; cost <= 0 {
+		t.Fatal("cost per element not measured")
+	}
+}
+
+func TestParallelForEach(t *testing.T) {
+	testParallelForEach(t, false)
+}
+
+func TestParallelForEach_archetype(t *testing.T) {
+	testParallelForEach(t, true)
+}
+
+func Test_parallelChunkSize(t *testing.T) {
+	if chunk := parallelChunkSize(100000, 1, 0); chunk != 100000 {
+		t.Fatalf("single worker, got %d", chunk)
+	}
+	if chunk := parallelChunkSize(100000, 8, 0); chunk != 3125 {
+		t.Fatalf("unmeasured, got %d", chunk)
+	}
+	// 10ns per element, chunks of 100us
+	if chunk := parallelChunkSize(100000, 8, 10); chunk != 10000 {
+		t.Fatalf("measured, got %d", chunk)
+	}
+	// the whole work is shorter than a chunk
+	if chunk := parallelChunkSize(1000, 8, 10); chunk != 1000 {
+		t.Fatalf("small work, got %d", chunk)
+	}
+}
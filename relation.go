@@ -0,0 +1,201 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+type RelationObject interface {
+	__RelationIdentification()
+}
+
+type relationIdentification struct{}
+
+func (r relationIdentification) __RelationIdentification() {}
+
+// Relation declare a relation kind by embedding, e.g.
+//
+//	type Follows struct {
+//		ecs.Relation[Follows]
+//	}
+//
+// a relation attaches a source entity to a target entity, an entity can have many targets
+type Relation[R RelationObject] struct {
+	relationIdentification
+}
+
+type relationOperate uint8
+
+const (
+	relationOperateAdd relationOperate = iota
+	relationOperateRemove
+	relationOperateDestroy
+)
+
+type relationOp struct {
+	op     relationOperate
+	typ    reflect.Type
+	source Entity
+	target Entity
+}
+
+// relationSet the (source, target) pairs of a relation kind, indexed both ways
+type relationSet struct {
+	targets map[Entity][]Entity
+	sources map[Entity][]Entity
+}
+
+func newRelationSet() *relationSet {
+	return &relationSet{
+		targets: map[Entity][]Entity{},
+		sources: map[Entity][]Entity{},
+	}
+}
+
+func (s *relationSet) add(source Entity, target Entity) {
+	for _, e := range s.targets[source] {
+		if e == target {
+			return
+		}
+	}
+	s.targets[source] = append(s.targets[source], target)
+	s.sources[target] = append(s.sources[target], source)
+}
+
+func (s *relationSet) remove(source Entity, target Entity) {
+	removeRelationPair(s.targets, source, target)
+	removeRelationPair(s.sources, target, source)
+}
+
+func (s *relationSet) removeEntity(entity Entity) {
+	for _, target := range s.targets[entity] {
+		removeRelationPair(s.sources, target, entity)
+	}
+	delete(s.targets, entity)
+	for _, source := range s.sources[entity] {
+		removeRelationPair(s.targets, source, entity)
+	}
+	delete(s.sources, entity)
+}
+
+func removeRelationPair(m map[Entity][]Entity, key Entity, value Entity) {
+	list := m[key]
+	for i, e := range list {
+		if e == value {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(m, key)
+	} else {
+		m[key] = list
+	}
+}
+
+// relations typed (source, relation, target) pairs, deferred and indexed like the hierarchy and
+// applied at the next flush, pairs are removed when either endpoint is destroyed
+type relations struct {
+	deferredOps[relationOp]
+	sets map[reflect.Type]*relationSet
+	// sets restored from a snapshot by type name, bound to their type at the first flush using it
+	restored map[string]*relationSet
+}
+
+func newRelations() *relations {
+	return &relations{
+		sets:     map[reflect.Type]*relationSet{},
+		restored: map[string]*relationSet{},
+	}
+}
+
+func (r *relations) operate(op relationOperate, typ reflect.Type, source Entity, target Entity) {
+	r.push(relationOp{op: op, typ: typ, source: source, target: target})
+}
+
+func (r *relations) getSet(typ reflect.Type) *relationSet {
+	if set, ok := r.sets[typ]; ok || len(r.restored) == 0 {
+		return set
+	}
+	return r.restored[componentTypeName(typ)]
+}
+
+// bind move a restored set to its type, only called in flush
+func (r *relations) bind(typ reflect.Type) (*relationSet, bool) {
+	set, ok := r.sets[typ]
+	if ok || len(r.restored) == 0 {
+		return set, ok
+	}
+	name := componentTypeName(typ)
+	if set, ok = r.restored[name]; ok {
+		delete(r.restored, name)
+		r.sets[typ] = set
+	}
+	return set, ok
+}
+
+func (r *relations) flush(world *ecsWorld) {
+	for _, op := range r.take() {
+		switch op.op {
+		case relationOperateAdd:
+			if !world.entities.Exist(op.source) || !world.entities.Exist(op.target) {
+				continue
+			}
+			set, ok := r.bind(op.typ)
+			if !ok {
+				set = newRelationSet()
+				r.sets[op.typ] = set
+			}
+			set.add(op.source, op.target)
+		case relationOperateRemove:
+			if set, ok := r.bind(op.typ); ok {
+				set.remove(op.source, op.target)
+			}
+		case relationOperateDestroy:
+			for _, set := range r.sets {
+				set.removeEntity(op.source)
+			}
+			for _, set := range r.restored {
+				set.removeEntity(op.source)
+			}
+		}
+	}
+}
+
+// AddRelation attach source to target with relation R, takes effect at the next flush
+func AddRelation[R RelationObject](getter IUtilityGetter, source Entity, target Entity) {
+	getter.getWorld().base().relations.operate(relationOperateAdd, TypeOf[R](), source, target)
+}
+
+func RemoveRelation[R RelationObject](getter IUtilityGetter, source Entity, target Entity) {
+	getter.getWorld().base().relations.operate(relationOperateRemove, TypeOf[R](), source, target)
+}
+
+// GetRelationTargets get the targets of source under relation R inside a system, the returned
+// slice must not be modified
+func GetRelationTargets[R RelationObject](sys ISystem, source Entity) []Entity {
+	set := sys.World().base().relations.getSet(TypeOf[R]())
+	if set == nil {
+		return nil
+	}
+	return set.targets[source]
+}
+
+// GetRelationSources get the entities with relation R to target inside a system, the returned
+// slice must not be modified
+func GetRelationSources[R RelationObject](sys ISystem, target Entity) []Entity {
+	set := sys.World().base().relations.getSet(TypeOf[R]())
+	if set == nil {
+		return nil
+	}
+	return set.sources[target]
+}
+
+// ShapeWithRelation iterate the shape over entities with relation R to target
+func ShapeWithRelation[R RelationObject, T any](shape *Shape[T], target Entity) IShapeIterator[T] {
+	return shape.getByEntities(GetRelationSources[R](shape.sys, target))
+}
+
+// ShapeOfRelationTargets iterate the shape over the targets of source under relation R
+func ShapeOfRelationTargets[R RelationObject, T any](shape *Shape[T], source Entity) IShapeIterator[T] {
+	return shape.getByEntities(GetRelationTargets[R](shape.sys, source))
+}
@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"sort"
+	"testing"
+)
+
+type __relation_Test_Follows struct {
+	Relation[__relation_Test_Follows]
+}
+
+type __relation_Test_MemberOf struct {
+	Relation[__relation_Test_MemberOf]
+}
+
+type __relation_Test_C_1 struct {
+	Component[__relation_Test_C_1]
+	Field1 int
+}
+
+type __relation_Test_Shape_1 struct {
+	c1 *__relation_Test_C_1
+}
+
+type __relation_Test_S_1 struct {
+	System[__relation_Test_S_1]
+
+	shape     *Shape[__relation_Test_Shape_1]
+	leader    Entity
+	followers []int
+	targets   []int
+	guild     []Entity
+}
+
+func (s *__relation_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__relation_Test_C_1{})
+	s.shape = NewShape[__relation_Test_Shape_1](si)
+	return nil
+}
+
+func (s *__relation_Test_S_1) Update(event Event) {
+	s.followers, s.targets = nil, nil
+	iter := ShapeWithRelation[__relation_Test_Follows](s.shape, s.leader)
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		s.followers = append(s.followers, v.c1.Field1)
+		targets := ShapeOfRelationTargets[__relation_Test_Follows](s.shape, v.c1.Owner())
+		for t := targets.Begin(); !targets.End(); t = targets.Next() {
+			s.targets = append(s.targets, t.c1.Field1)
+		}
+	}
+	sort.Ints(s.followers)
+	s.guild = GetRelationSources[__relation_Test_MemberOf](s, s.leader)
+}
+
+func TestAddRelation(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__relation_Test_S_1](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__relation_Test_S_1]())
+	sys := s.(*__relation_Test_S_1)
+
+	var entities []Entity
+	for i := 0; i < 4; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__relation_Test_C_1{Field1: i})
+		entities = append(entities, e)
+	}
+	sys.leader = entities[0]
+	AddRelation[__relation_Test_Follows](world, entities[1], entities[0])
+	AddRelation[__relation_Test_Follows](world, entities[2], entities[0])
+	AddRelation[__relation_Test_Follows](world, entities[2], entities[0])
+	AddRelation[__relation_Test_MemberOf](world, entities[3], entities[0])
+	world.Update()
+
+	if len(sys.followers) != 2 || sys.followers[0] != 1 || sys.followers[1] != 2 {
+		t.Fatalf("followers, got %v", sys.followers)
+	}
+	if len(sys.targets) != 2 || sys.targets[0] != 0 || sys.targets[1] != 0 {
+		t.Fatalf("targets, got %v", sys.targets)
+	}
+	if len(sys.guild) != 1 || sys.guild[0] != entities[3] {
+		t.Fatalf("guild, got %v", sys.guild)
+	}
+
+	RemoveRelation[__relation_Test_Follows](world, entities[1], entities[0])
+	world.Update()
+	if len(sys.followers) != 1 || sys.followers[0] != 2 {
+		t.Fatalf("followers after remove, got %v", sys.followers)
+	}
+
+	// destroying the target removes all pairs
+	ig := IWorld(world)
+	SyncWrapper{world: &ig}.DestroyEntity(entities[0])
+	world.Update()
+	for _, set := range world.relations.sets {
+		if len(set.targets) != 0 || len(set.sources) != 0 {
+			t.Fatal("relations not cleaned up")
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package ecs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+const changeSetMagic uint32 = 0x52534345 // "ECSR"
+
+// replicaMaxFields fields beyond this are merged into the last one
+const replicaMaxFields = 64
+
+type replicaField struct {
+	offset uintptr
+	size   uintptr
+}
+
+// replicaLayout field spans of a component type, the Component header is excluded
+type replicaLayout struct {
+	hash   uint64
+	size   uintptr
+	offset uintptr
+	fields []replicaField
+}
+
+func newReplicaLayout(typ reflect.Type) *replicaLayout {
+	l := &replicaLayout{
+		hash:   componentLayoutHash(typ),
+		size:   typ.Size(),
+		offset: typ.Size(),
+	}
+	if typ.NumField() > 1 {
+		l.offset = typ.Field(1).Offset
+	}
+	for i := 1; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if len(l.fields) == replicaMaxFields {
+			last := &l.fields[replicaMaxFields-1]
+			last.size = f.Offset + f.Type.Size() - last.offset
+			continue
+		}
+		l.fields = append(l.fields, replicaField{offset: f.Offset, size: f.Type.Size()})
+	}
+	return l
+}
+
+func (l *replicaLayout) data(p unsafe.Pointer) []byte {
+	return unsafe.Slice((*byte)(unsafe.Add(p, l.offset)), l.size-l.offset)
+}
+
+func (l *replicaLayout) field(p unsafe.Pointer, i int) []byte {
+	return unsafe.Slice((*byte)(unsafe.Add(p, l.fields[i].offset)), l.fields[i].size)
+}
+
+// replicaTrack records the changes of a replicable component type between two encodes
+type replicaTrack struct {
+	meta    *ComponentMetaInfo
+	layout  *replicaLayout
+	added   map[Entity]struct{}
+	removed map[Entity]struct{}
+	shadow  map[Entity][]byte
+
+	addBuf    bytes.Buffer
+	modBuf    bytes.Buffer
+	addWriter *snapshotWriter
+	modWriter *snapshotWriter
+}
+
+// onAdd an add after a remove of the same changeset is encoded as an add overwriting the component
+// of the follower
+func (t *replicaTrack) onAdd(entity Entity) {
+	delete(t.removed, entity)
+	t.added[entity] = struct{}{}
+}
+
+func (t *replicaTrack) onRemove(entity Entity) {
+	if _, ok := t.added[entity]; ok {
+		delete(t.added, entity)
+		if _, ok := t.shadow[entity]; !ok {
+			return
+		}
+	}
+	t.removed[entity] = struct{}{}
+}
+
+func (t *replicaTrack) encode(sw *snapshotWriter, set IComponentSet) {
+	sw.u16(t.meta.it)
+	sw.i64(int64(t.layout.hash))
+
+	sw.u32(uint32(len(t.removed)))
+	for entity := range t.removed {
+		sw.i64(int64(entity))
+		delete(t.shadow, entity)
+	}
+	t.removed = map[Entity]struct{}{}
+
+	// added, an add of an existing component overwrites it
+	t.addBuf.Reset()
+	t.modBuf.Reset()
+	added, modified := uint32(0), uint32(0)
+	writeAdd := func(entity Entity, p unsafe.Pointer) {
+		data := t.layout.data(p)
+		t.shadow[entity] = append(t.shadow[entity][:0], data...)
+		t.addWriter.i64(int64(entity))
+		t.addWriter.write(data)
+		added++
+	}
+	for entity := range t.added {
+		var p unsafe.Pointer
+		if set != nil {
+			p = set.getPointerByEntity(entity)
+		}
+		if p != nil {
+			writeAdd(entity, p)
+		}
+	}
+
+	// modified, compared field by field with the last encoded value
+	length := 0
+	if set != nil {
+		length = set.Len()
+	}
+	for i := 0; i < length; i++ {
+		p := set.getPointerByIndex(int64(i))
+		entity := (*EmptyComponent)(p).Owner()
+		if _, ok := t.added[entity]; ok {
+			continue
+		}
+		shadow, ok := t.shadow[entity]
+		if !ok {
+			// existed before replication enabled
+			writeAdd(entity, p)
+			continue
+		}
+		mask := uint64(0)
+		for f := range t.layout.fields {
+			field := t.layout.field(p, f)
+			start := t.layout.fields[f].offset - t.layout.offset
+			if !bytes.Equal(field, shadow[start:start+uintptr(len(field))]) {
+				mask |= 1 << f
+			}
+		}
+		if mask == 0 {
+			continue
+		}
+		t.modWriter.i64(int64(entity))
+		t.modWriter.i64(int64(mask))
+		for f := range t.layout.fields {
+			if mask&(1<<f) == 0 {
+				continue
+			}
+			field := t.layout.field(p, f)
+			start := t.layout.fields[f].offset - t.layout.offset
+			copy(shadow[start:], field)
+			t.modWriter.write(field)
+		}
+		modified++
+	}
+
+	sw.u32(added)
+	sw.write(t.addBuf.Bytes())
+	sw.u32(modified)
+	sw.write(t.modBuf.Bytes())
+
+	t.added = map[Entity]struct{}{}
+}
+
+// replicator change tracking layer over ComponentCollection.opExecute
+type replicator struct {
+	world     *ecsWorld
+	seq       uint64 // sequence of the last encoded changeset, starts at 1
+	tracks    map[uint16]*replicaTrack
+	order     []uint16
+	destroyed []Entity
+}
+
+func newReplicator(world *ecsWorld) *replicator {
+	return &replicator{
+		world:  world,
+		tracks: map[uint16]*replicaTrack{},
+	}
+}
+
+func (r *replicator) track(meta *ComponentMetaInfo) {
+	if _, ok := r.tracks[meta.it]; ok {
+		return
+	}
+	t := &replicaTrack{
+		meta:    meta,
+		layout:  newReplicaLayout(meta.typ),
+		added:   map[Entity]struct{}{},
+		removed: map[Entity]struct{}{},
+		shadow:  map[Entity][]byte{},
+	}
+	t.addWriter = &snapshotWriter{w: &t.addBuf}
+	t.modWriter = &snapshotWriter{w: &t.modBuf}
+	r.tracks[meta.it] = t
+	r.order = append(r.order, meta.it)
+}
+
+func (r *replicator) getTrack(it uint16) *replicaTrack {
+	if r == nil {
+		return nil
+	}
+	return r.tracks[it]
+}
+
+func (r *replicator) onDestroy(info *EntityInfo) {
+	for _, it := range r.order {
+		if info.compound.Exist(it) {
+			r.destroyed = append(r.destroyed, info.entity)
+			return
+		}
+	}
+}
+
+func (r *replicator) encode(writer io.Writer) error {
+	sw := &snapshotWriter{w: writer}
+	r.seq++
+	sw.u32(changeSetMagic)
+	sw.i64(int64(r.seq))
+	sw.i64(int64(r.world.frame))
+
+	sw.u32(uint32(len(r.destroyed)))
+	for _, entity := range r.destroyed {
+		sw.i64(int64(entity))
+	}
+	r.destroyed = r.destroyed[:0]
+
+	sw.u16(uint16(len(r.order)))
+	collections := r.world.components.getCollections()
+	for _, it := range r.order {
+		var set IComponentSet
+		if s := collections.Get(it); s != nil {
+			set = *s
+		}
+		r.tracks[it].encode(sw, set)
+	}
+	return sw.err
+}
+
+// EnableReplication mark a component type replicable, its adds, removes and field modifications
+// are recorded and encoded by EncodeChangeSet. Only normal and disposable components are supported.
+func EnableReplication[T ComponentObject, TP ComponentPointer[T]](world IWorld) {
+	w := world.base()
+	w.checkMainThread()
+	meta := w.getOrCreateComponentMetaInfo(TP(new(T)))
+	if meta.componentType&ComponentTypeFreeMask > 0 {
+		panic("free component can not be replicated")
+	}
+	if w.replicator == nil {
+		w.replicator = newReplicator(w)
+	}
+	w.replicator.track(meta)
+}
+
+// EncodeChangeSet encode the changes of replicable components since the last call. The first
+// changeset contains all existing components, changesets must be applied in the order they are
+// encoded. Must be called on the main thread between frames.
+func EncodeChangeSet(world IWorld, writer io.Writer) error {
+	w := world.base()
+	w.checkMainThread()
+	if w.replicator == nil {
+		return errors.New("replication is not enabled")
+	}
+	return w.replicator.encode(writer)
+}
+
+// Replica applies changesets of a leader world to a follower world. Leader entities are mapped
+// to local entities, entities already existing in the follower (e.g. restored by LoadWorld) map
+// to themselves. Component types must be registered in the follower with the same ids.
+type Replica struct {
+	world    *SyncWorld
+	entities map[Entity]Entity
+	layouts  map[uint16]*replicaLayout
+	pending  replicaPending
+	seq      uint64
+	frame    uint64
+}
+
+// replicaPending the adds and removes queued on the follower and not yet flushed, changesets
+// applied before the next Update of the follower write to the queued components
+type replicaPending struct {
+	frame   uint64
+	added   map[uint16]map[Entity]unsafe.Pointer
+	removed map[uint16]map[Entity]struct{}
+}
+
+// reset forget the queued operations once the follower flushed them
+func (p *replicaPending) reset(frame uint64) {
+	if p.added != nil && p.frame == frame {
+		return
+	}
+	p.frame = frame
+	p.added = map[uint16]map[Entity]unsafe.Pointer{}
+	p.removed = map[uint16]map[Entity]struct{}{}
+}
+
+func (p *replicaPending) add(it uint16, entity Entity, com unsafe.Pointer) {
+	if p.added[it] == nil {
+		p.added[it] = map[Entity]unsafe.Pointer{}
+	}
+	p.added[it][entity] = com
+	delete(p.removed[it], entity)
+}
+
+func (p *replicaPending) remove(it uint16, entity Entity) {
+	if p.removed[it] == nil {
+		p.removed[it] = map[Entity]struct{}{}
+	}
+	p.removed[it][entity] = struct{}{}
+	delete(p.added[it], entity)
+}
+
+func NewReplica(world *SyncWorld) *Replica {
+	r := &Replica{
+		world:    world,
+		entities: map[Entity]Entity{},
+		layouts:  map[uint16]*replicaLayout{},
+	}
+	world.entities.Range(func(info *EntityInfo) bool {
+		r.entities[info.entity] = info.entity
+		return true
+	})
+	return r
+}
+
+// Frame the leader frame of the last applied changeset
+func (r *Replica) Frame() uint64 {
+	return r.frame
+}
+
+// Entity get the local entity of a leader entity
+func (r *Replica) Entity(remote Entity) (Entity, bool) {
+	e, ok := r.entities[remote]
+	return e, ok
+}
+
+// Apply a changeset on the main thread of the follower, adds and removes take effect on the next
+// Update of the follower as usual, modifications are written in place, or to the components
+// still queued by an earlier changeset. Changesets must be applied in order, a changeset applied
+// already or following a missing one is rejected without changes.
+func (r *Replica) Apply(reader io.Reader) error {
+	w := &r.world.ecsWorld
+	w.checkMainThread()
+	r.pending.reset(w.frame)
+
+	sr := &snapshotReader{r: reader}
+	if sr.u32() != changeSetMagic {
+		if sr.err != nil {
+			return sr.err
+		}
+		return errors.New("apply changeset: invalid changeset")
+	}
+	seq := uint64(sr.i64())
+	frame := uint64(sr.i64())
+	if sr.err != nil {
+		return sr.err
+	}
+	if seq <= r.seq {
+		return fmt.Errorf("apply changeset: stale changeset %d of frame %d, applied %d of frame %d", seq, frame, r.seq, r.frame)
+	}
+	if seq != r.seq+1 {
+		return fmt.Errorf("apply changeset: changeset %d of frame %d does not follow %d, changesets are missing", seq, frame, r.seq)
+	}
+	r.seq, r.frame = seq, frame
+
+	n := sr.u32()
+	for i := uint32(0); i < n && sr.err == nil; i++ {
+		remote := Entity(sr.i64())
+		if local, ok := r.entities[remote]; ok {
+			r.world.DestroyEntity(local)
+			delete(r.entities, remote)
+		}
+	}
+
+	typeCount := int(sr.u16())
+	for i := 0; i < typeCount && sr.err == nil; i++ {
+		it := sr.u16()
+		hash := uint64(sr.i64())
+		if sr.err != nil {
+			break
+		}
+		meta := w.componentMeta.infos.Get(it)
+		if meta == nil {
+			return fmt.Errorf("apply changeset: component %d is not registered", it)
+		}
+		layout, ok := r.layouts[it]
+		if !ok {
+			layout = newReplicaLayout(meta.typ)
+			r.layouts[it] = layout
+		}
+		if layout.hash != hash {
+			return fmt.Errorf("apply changeset: component %s layout changed", meta.typ.String())
+		}
+		if err := r.applyType(sr, meta, layout); err != nil {
+			return err
+		}
+	}
+	return sr.err
+}
+
+func (r *Replica) applyType(sr *snapshotReader, meta *ComponentMetaInfo, layout *replicaLayout) error {
+	w := &r.world.ecsWorld
+	var set IComponentSet
+	if s := w.components.getCollections().Get(meta.it); s != nil {
+		set = *s
+	}
+	// get the component the changes are written to, a queued add or the committed component
+	get := func(local Entity) unsafe.Pointer {
+		if p, ok := r.pending.added[meta.it][local]; ok {
+			return p
+		}
+		if _, ok := r.pending.removed[meta.it][local]; ok || set == nil {
+			return nil
+		}
+		return set.getPointerByEntity(local)
+	}
+
+	// removed
+	n := sr.u32()
+	for i := uint32(0); i < n && sr.err == nil; i++ {
+		local, ok := r.entities[Entity(sr.i64())]
+		if !ok {
+			continue
+		}
+		if get(local) != nil {
+			w.deleteComponentByIntType(local, meta.it)
+			r.pending.remove(meta.it, local)
+		}
+	}
+
+	// added, after a queued remove the component is added again, so that it survives the flush
+	data := make([]byte, layout.size-layout.offset)
+	n = sr.u32()
+	for i := uint32(0); i < n && sr.err == nil; i++ {
+		remote := Entity(sr.i64())
+		sr.read(data)
+		if sr.err != nil {
+			break
+		}
+		local, ok := r.entities[remote]
+		if !ok {
+			local = w.newEntity().Entity()
+			r.entities[remote] = local
+		}
+		if p := get(local); p != nil {
+			copy(layout.data(p), data)
+			continue
+		}
+		ins := reflect.New(meta.typ)
+		copy(layout.data(ins.UnsafePointer()), data)
+		w.addComponent(local, ins.Interface().(IComponent))
+		r.pending.add(meta.it, local, ins.UnsafePointer())
+	}
+
+	// modified
+	n = sr.u32()
+	for i := uint32(0); i < n && sr.err == nil; i++ {
+		remote := Entity(sr.i64())
+		mask := uint64(sr.i64())
+		var p unsafe.Pointer
+		if local, ok := r.entities[remote]; ok {
+			p = get(local)
+		}
+		for f := range layout.fields {
+			if mask&(1<<f) == 0 {
+				continue
+			}
+			if p != nil {
+				sr.read(layout.field(p, f))
+			} else {
+				sr.read(make([]byte, layout.fields[f].size))
+			}
+		}
+	}
+	return sr.err
+}
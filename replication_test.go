@@ -0,0 +1,217 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+)
+
+type __replication_Test_C_1 struct {
+	Component[__replication_Test_C_1]
+	X, Y, Z int
+}
+
+type __replication_Test_C_2 struct {
+	Component[__replication_Test_C_2]
+	HP int
+}
+
+func TestReplica_Apply(t *testing.T) {
+	leader := NewSyncWorld(NewDefaultWorldConfig())
+	EnableReplication[__replication_Test_C_1](leader)
+	EnableReplication[__replication_Test_C_2](leader)
+	leader.Startup()
+
+	follower := NewSyncWorld(NewDefaultWorldConfig())
+	EnableReplication[__replication_Test_C_1](follower)
+	EnableReplication[__replication_Test_C_2](follower)
+	follower.Startup()
+	replica := NewReplica(follower)
+
+	sync := func() {
+		leader.Update()
+		buf := &bytes.Buffer{}
+		if err := EncodeChangeSet(leader, buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := replica.Apply(buf); err != nil {
+			t.Fatal(err)
+		}
+		follower.Update()
+	}
+	check := func(step string) {
+		replicationTestCheck(t, step, leader, follower, replica)
+	}
+
+	var entities []Entity
+	for i := 0; i < 5; i++ {
+		e := leader.NewEntity()
+		leader.Add(e, &__replication_Test_C_1{X: i}, &__replication_Test_C_2{HP: 100})
+		entities = append(entities, e)
+	}
+	sync()
+	check("add")
+
+	set := leader.getComponentSet(TypeOf[__replication_Test_C_1]()).(*ComponentSet[__replication_Test_C_1])
+	set.Get(entities[1]).Y = 10
+	set.Get(entities[2]).Z = 20
+	sync()
+	check("modify")
+
+	leader.Remove(entities[0], &__replication_Test_C_2{})
+	leader.DestroyEntity(entities[3])
+	sync()
+	check("remove")
+	if _, ok := replica.Entity(entities[3]); ok {
+		t.Error("destroyed entity still mapped")
+	}
+}
+
+func replicationTestCheck(t *testing.T, step string, leader *SyncWorld, follower *SyncWorld, replica *Replica) {
+	t.Helper()
+	for _, typ := range []uint16{
+		GetComponentMeta[__replication_Test_C_1](leader).it,
+		GetComponentMeta[__replication_Test_C_2](leader).it,
+	} {
+		ls, fs := leader.getComponentSetByIntType(typ), follower.getComponentSetByIntType(typ)
+		if ls.Len() != fs.Len() {
+			t.Fatalf("%s: component count mismatch, leader: %d, follower: %d", step, ls.Len(), fs.Len())
+		}
+		for i := 0; i < ls.Len(); i++ {
+			lp := ls.getPointerByIndex(int64(i))
+			local, ok := replica.Entity((*EmptyComponent)(lp).Owner())
+			if !ok {
+				t.Fatalf("%s: entity not replicated", step)
+			}
+			layout := newReplicaLayout(ls.GetElementMeta().typ)
+			fp := fs.getPointerByEntity(local)
+			if fp == nil || !bytes.Equal(layout.data(lp), layout.data(fp)) {
+				t.Fatalf("%s: component mismatch", step)
+			}
+		}
+	}
+}
+
+func TestReplica_ApplyReAdd(t *testing.T) {
+	leader := NewSyncWorld(NewDefaultWorldConfig())
+	EnableReplication[__replication_Test_C_1](leader)
+	EnableReplication[__replication_Test_C_2](leader)
+	leader.Startup()
+
+	follower := NewSyncWorld(NewDefaultWorldConfig())
+	EnableReplication[__replication_Test_C_1](follower)
+	EnableReplication[__replication_Test_C_2](follower)
+	follower.Startup()
+	replica := NewReplica(follower)
+
+	apply := func() {
+		buf := &bytes.Buffer{}
+		if err := EncodeChangeSet(leader, buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := replica.Apply(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := leader.NewEntity()
+	leader.Add(e, &__replication_Test_C_1{X: 1}, &__replication_Test_C_2{HP: 100})
+	leader.Update()
+	apply()
+	follower.Update()
+	replicationTestCheck(t, "add", leader, follower, replica)
+
+	// removed and added again in one frame
+	leader.deleteComponent(e, &__replication_Test_C_1{})
+	leader.addComponent(e, &__replication_Test_C_1{X: 2})
+	leader.Update()
+	apply()
+	follower.Update()
+	replicationTestCheck(t, "remove and add in one frame", leader, follower, replica)
+
+	// removed and added again in two frames of one changeset
+	leader.Remove(e, &__replication_Test_C_1{})
+	leader.Update()
+	leader.Add(e, &__replication_Test_C_1{X: 3})
+	leader.Update()
+	apply()
+	follower.Update()
+	replicationTestCheck(t, "remove and add in one changeset", leader, follower, replica)
+
+	// removed and added again in two changesets before the follower updates
+	leader.Remove(e, &__replication_Test_C_1{})
+	leader.Update()
+	apply()
+	leader.Add(e, &__replication_Test_C_1{X: 4})
+	leader.Update()
+	apply()
+	follower.Update()
+	replicationTestCheck(t, "remove and add before the follower updates", leader, follower, replica)
+
+	// modified while the add is queued on the follower
+	e2 := leader.NewEntity()
+	leader.Add(e2, &__replication_Test_C_2{HP: 50})
+	leader.Update()
+	apply()
+	leader.getComponentSet(TypeOf[__replication_Test_C_2]()).(*ComponentSet[__replication_Test_C_2]).Get(e2).HP = 40
+	leader.Update()
+	apply()
+	follower.Update()
+	replicationTestCheck(t, "modify a queued add", leader, follower, replica)
+}
+
+func TestReplica_ApplyOutOfOrder(t *testing.T) {
+	leader := NewSyncWorld(NewDefaultWorldConfig())
+	EnableReplication[__replication_Test_C_1](leader)
+	EnableReplication[__replication_Test_C_2](leader)
+	leader.Startup()
+
+	follower := NewSyncWorld(NewDefaultWorldConfig())
+	EnableReplication[__replication_Test_C_1](follower)
+	EnableReplication[__replication_Test_C_2](follower)
+	follower.Startup()
+	replica := NewReplica(follower)
+
+	e := leader.NewEntity()
+	leader.Add(e, &__replication_Test_C_1{X: 1}, &__replication_Test_C_2{HP: 100})
+	var changesets [][]byte
+	for i := 0; i < 3; i++ {
+		leader.Update()
+		leader.getComponentSet(TypeOf[__replication_Test_C_1]()).(*ComponentSet[__replication_Test_C_1]).Get(e).Y = i
+		buf := &bytes.Buffer{}
+		if err := EncodeChangeSet(leader, buf); err != nil {
+			t.Fatal(err)
+		}
+		changesets = append(changesets, buf.Bytes())
+	}
+
+	apply := func(i int) error {
+		err := replica.Apply(bytes.NewReader(changesets[i]))
+		follower.Update()
+		return err
+	}
+	if err := apply(1); err == nil {
+		t.Fatal("changeset applied before the first one")
+	}
+	if err := apply(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := apply(2); err == nil {
+		t.Fatal("changeset applied after a missing one")
+	}
+	if err := apply(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := apply(1); err == nil {
+		t.Fatal("stale changeset applied")
+	}
+	if err := apply(0); err == nil {
+		t.Fatal("stale changeset applied")
+	}
+	if err := apply(2); err != nil {
+		t.Fatal(err)
+	}
+	if replica.Frame() != leader.frame {
+		t.Fatalf("applied frame, want %d, got %d", leader.frame, replica.Frame())
+	}
+	replicationTestCheck(t, "in order", leader, follower, replica)
+}
@@ -0,0 +1,154 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schedule snapshot of the system schedule, systems of a batch run in parallel, batches and order
+// groups of a stage run one after another
+type Schedule struct {
+	Systems []ScheduleSystem `json:"systems"`
+	Stages  []ScheduleStage  `json:"stages"`
+}
+
+type ScheduleSystem struct {
+	Name        string   `json:"name"`
+	Reads       []string `json:"reads,omitempty"`
+	Writes      []string `json:"writes,omitempty"`
+	EventReads  []string `json:"event_reads,omitempty"`
+	EventWrites []string `json:"event_writes,omitempty"`
+}
+
+type ScheduleStage struct {
+	Stage  string          `json:"stage"`
+	Groups []ScheduleGroup `json:"groups"`
+}
+
+type ScheduleGroup struct {
+	Order   Order          `json:"order"`
+	Batches [][]string     `json:"batches"`
+	Edges   []ScheduleEdge `json:"edges,omitempty"`
+}
+
+// ScheduleEdge From runs before To in the group, because of the conflicting types or a constraint
+type ScheduleEdge struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Conflicts  []string `json:"conflicts,omitempty"`
+	Constraint bool     `json:"constraint,omitempty"`
+}
+
+// ExportSchedule get the schedule of the systems, stages without systems are omitted. Must be
+// called on the main thread, for a running AsyncWorld use it in Sync/Wait.
+func (w *ecsWorld) ExportSchedule() *Schedule {
+	w.checkMainThread()
+	return w.systemFlow.schedule()
+}
+
+func (p *systemFlow) schedule() *Schedule {
+	s := &Schedule{}
+	for _, sys := range p.ordered {
+		ss := ScheduleSystem{Name: sys.Type().String()}
+		for typ, r := range sys.GetRequirements() {
+			if r.getPermission() == ComponentReadOnly {
+				ss.Reads = append(ss.Reads, typ.String())
+			} else {
+				ss.Writes = append(ss.Writes, typ.String())
+			}
+		}
+		for typ, permission := range sys.getEventAccess() {
+			if permission == ComponentReadOnly {
+				ss.EventReads = append(ss.EventReads, typ.String())
+			} else {
+				ss.EventWrites = append(ss.EventWrites, typ.String())
+			}
+		}
+		sort.Strings(ss.Reads)
+		sort.Strings(ss.Writes)
+		sort.Strings(ss.EventReads)
+		sort.Strings(ss.EventWrites)
+		s.Systems = append(s.Systems, ss)
+	}
+
+	for _, period := range p.stageList {
+		stage := ScheduleStage{Stage: period.String()}
+		for _, sg := range p.stages[period] {
+			if sg.systemCount() == 0 {
+				continue
+			}
+			group := ScheduleGroup{Order: sg.order}
+			// a private iterator, the embedded one is used by systemUpdate
+			iter := sg.iter()
+			for ss := iter.Begin(); !iter.End(); ss = iter.Next() {
+				batch := make([]string, len(ss))
+				for i, sys := range ss {
+					batch[i] = sys.Type().String()
+				}
+				sort.Strings(batch)
+				group.Batches = append(group.Batches, batch)
+			}
+			for i, from := range sg.systems {
+				for _, to := range sg.systems[i+1:] {
+					edge := ScheduleEdge{
+						From:       from.val.Type().String(),
+						To:         to.val.Type().String(),
+						Constraint: precedes(from.val, to.val),
+					}
+					for _, typ := range from.conflicts(to) {
+						edge.Conflicts = append(edge.Conflicts, typ.String())
+					}
+					if len(edge.Conflicts) == 0 && !edge.Constraint {
+						continue
+					}
+					sort.Strings(edge.Conflicts)
+					group.Edges = append(group.Edges, edge)
+				}
+			}
+			stage.Groups = append(stage.Groups, group)
+		}
+		if len(stage.Groups) > 0 {
+			s.Stages = append(s.Stages, stage)
+		}
+	}
+	return s
+}
+
+func (s *Schedule) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// DOT the schedule in Graphviz DOT, a cluster per stage and order group, a node per system in a
+// stage labeled with its batch, and an edge per conflict or constraint
+func (s *Schedule) DOT() string {
+	b := &strings.Builder{}
+	b.WriteString("digraph schedule {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for si, stage := range s.Stages {
+		fmt.Fprintf(b, "\tsubgraph cluster_%d {\n", si)
+		fmt.Fprintf(b, "\t\tlabel=%q;\n", stage.Stage)
+		for gi, group := range stage.Groups {
+			fmt.Fprintf(b, "\t\tsubgraph cluster_%d_%d {\n", si, gi)
+			fmt.Fprintf(b, "\t\t\tlabel=%q;\n", fmt.Sprintf("Order %d", group.Order))
+			for bi, batch := range group.Batches {
+				for _, name := range batch {
+					fmt.Fprintf(b, "\t\t\t%q [label=%q];\n", stage.Stage+"/"+name, fmt.Sprintf("%s\nbatch %d", name, bi))
+				}
+			}
+			b.WriteString("\t\t}\n")
+			for _, edge := range group.Edges {
+				label := strings.Join(edge.Conflicts, "\n")
+				if edge.Constraint {
+					label = strings.TrimPrefix(label+"\nconstraint", "\n")
+				}
+				fmt.Fprintf(b, "\t\t%q -> %q [label=%q];\n", stage.Stage+"/"+edge.From, stage.Stage+"/"+edge.To, label)
+			}
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package ecs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type __schedule_Test_C_1 struct {
+	Component[__schedule_Test_C_1]
+}
+
+type __schedule_Test_C_2 struct {
+	Component[__schedule_Test_C_2]
+}
+
+type __schedule_Test_S_1 struct {
+	System[__schedule_Test_S_1]
+}
+
+func (s *__schedule_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__schedule_Test_C_1{}, &ReadOnly[__schedule_Test_C_2]{})
+	return nil
+}
+
+func (s *__schedule_Test_S_1) Update(event Event) {}
+
+type __schedule_Test_S_2 struct {
+	System[__schedule_Test_S_2]
+}
+
+func (s *__schedule_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__schedule_Test_C_1]{}, &ReadOnly[__schedule_Test_C_2]{})
+	return nil
+}
+
+func (s *__schedule_Test_S_2) Update(event Event) {}
+
+type __schedule_Test_S_3 struct {
+	System[__schedule_Test_S_3]
+}
+
+func (s *__schedule_Test_S_3) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__schedule_Test_C_2]{})
+	return nil
+}
+
+func (s *__schedule_Test_S_3) Update(event Event) {}
+
+func Test_ecsWorld_ExportSchedule(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__schedule_Test_S_1](world)
+	RegisterSystem[__schedule_Test_S_2](world)
+	RegisterSystem[__schedule_Test_S_3](world)
+	world.Startup()
+
+	schedule := world.ExportSchedule()
+	if len(schedule.Systems) != 3 || len(schedule.Systems[0].Writes) != 1 || len(schedule.Systems[0].Reads) != 1 {
+		t.Fatalf("systems, got %+v", schedule.Systems)
+	}
+	if len(schedule.Stages) != 1 || schedule.Stages[0].Stage != "StageUpdate" {
+		t.Fatalf("stages, got %+v", schedule.Stages)
+	}
+	group := schedule.Stages[0].Groups[0]
+	if len(group.Batches) != 2 {
+		t.Fatalf("batches, got %v", group.Batches)
+	}
+	// only S_1 and S_2 conflict, on the component written by S_1
+	if len(group.Edges) != 1 || len(group.Edges[0].Conflicts) != 1 ||
+		!strings.HasSuffix(group.Edges[0].Conflicts[0], "__schedule_Test_C_1") {
+		t.Fatalf("edges, got %+v", group.Edges)
+	}
+
+	data, err := schedule.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Schedule{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Stages[0].Groups[0].Edges) != 1 {
+		t.Fatalf("json round trip, got %s", data)
+	}
+
+	dot := schedule.DOT()
+	if !strings.HasPrefix(dot, "digraph schedule {") || strings.Count(dot, "->") != 1 {
+		t.Fatalf("dot, got %s", dot)
+	}
+}
+
+func Test_ecsWorld_ExportSchedule_Async(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.FrameInterval = time.Millisecond
+	world := NewAsyncWorld(config)
+	RegisterSystem[__schedule_Test_S_1](world)
+	RegisterSystem[__schedule_Test_S_2](world)
+	RegisterSystem[__schedule_Test_S_3](world)
+	world.Startup()
+	defer world.Stop()
+
+	// exported on the world goroutine between frames, while the systems keep updating
+	for i := 0; i < 20; i++ {
+		done := make(chan *Schedule)
+		world.Sync(func(g SyncWrapper) error {
+			done <- world.ExportSchedule()
+			return nil
+		})
+		if schedule := <-done; len(schedule.Stages) != 1 || len(schedule.Stages[0].Groups[0].Batches) != 2 {
+			t.Fatalf("stages, got %+v", schedule.Stages)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("export off the world goroutine not rejected")
+		}
+	}()
+	world.ExportSchedule()
+}
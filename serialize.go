@@ -1,7 +1,441 @@
 package ecs
 
-// TODO 世界的序列化、反序列化
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+const (
+	snapshotMagic   uint32 = 0x57534345 // "ECSW"
+	snapshotVersion uint16 = 4
+
+	// snapshotMaxBytes the limit of a length prefixed byte string, e.g. a custom serialized component
+	snapshotMaxBytes = 1 << 26
+	// snapshotMaxPrealloc counts read from a snapshot preallocate this many elements at most, a
+	// corrupted count fails at the end of the input instead of allocating it up front
+	snapshotMaxPrealloc = 1 << 16
+)
+
+var (
+	errSnapshotCount  = errors.New("load world: negative count in snapshot")
+	errSnapshotLength = errors.New("load world: byte length in snapshot exceeds the limit")
+)
+
+// ICustomSerialize overrides the default memory dump of a component type in world snapshots
 type ICustomSerialize interface {
 	Serialize() []byte
 	DeSerialize(b []byte)
 }
+
+// SaveWorld writes the committed state of the world (component meta, entities, entity id
+// generator, all component sets, the hierarchy and the relations) to writer. Operations not yet flushed are not included.
+// Must be called on the main thread, for a running AsyncWorld use it in Sync/Wait.
+// Raw component memory is written as is, snapshots are portable only between the same architecture.
+func SaveWorld(world IWorld, writer io.Writer) error {
+	w := world.base()
+	w.checkMainThread()
+
+	sw := &snapshotWriter{w: writer}
+	sw.u32(snapshotMagic)
+	sw.u16(snapshotVersion)
+
+	// component meta, the manifest of the registered types
+	manifest := ExportComponentManifest(w)
+	sw.u16(uint16(len(manifest.Components)))
+	for _, e := range manifest.Components {
+		sw.u16(e.ID)
+		sw.u8(uint8(w.componentMeta.GetComponentMetaInfoByIntType(e.ID).componentType))
+		sw.str(e.Name)
+		sw.u32(e.Size)
+		sw.i64(int64(e.Layout))
+	}
+
+	// entity id generator
+	g := w.idGenerator
+	sw.i32(int32(len(g.ids)))
+	for _, id := range g.ids {
+		sw.i32(id.index)
+		sw.i32(id.reuse)
+	}
+	sw.i32(g.free)
+	sw.i32(g.pending)
+	sw.i32(g.len)
+	sw.i32(g.delayFree)
+	for i := int32(0); i < g.delayFree; i++ {
+		sw.i32(g.removeDelay[i].index)
+		sw.i32(g.removeDelay[i].reuse)
+	}
+
+	// entities
+	sw.i32(int32(w.entities.Len()))
+	w.entities.Range(func(info *EntityInfo) bool {
+		sw.i64(int64(info.entity))
+		sw.u16(uint16(len(info.compound)))
+		for _, it := range info.compound {
+			sw.u16(it)
+		}
+		return sw.err == nil
+	})
+
+	// component sets
+	collections := w.components.getCollections()
+	sw.u16(uint16(collections.Len()))
+	collections.Range(func(set *IComponentSet) bool {
+		sw.u16((*set).GetElementMeta().it)
+		if sw.err == nil {
+			sw.err = (*set).serialize(sw.w)
+		}
+		return sw.err == nil
+	})
+
+	// hierarchy and relations, the relation kinds by type name
+	sw.relationSet(w.hierarchy.pairs)
+	sets := map[string]*relationSet{}
+	for name, set := range w.relations.restored {
+		sets[name] = set
+	}
+	for typ, set := range w.relations.sets {
+		sets[componentTypeName(typ)] = set
+	}
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sw.u16(uint16(len(names)))
+	for _, name := range names {
+		sw.str(name)
+		sw.relationSet(sets[name])
+	}
+
+	return sw.err
+}
+
+// ReadSnapshotManifest read the component types of a snapshot written by SaveWorld. Bind it to a
+// new world before registering the systems, so that the component ids match the snapshot:
+//
+//	manifest, err := ecs.ReadSnapshotManifest(bytes.NewReader(data))
+//	err = manifest.Bind(world)
+//	ecs.RegisterSystem[MoveSystem](world)
+//	err = ecs.LoadWorld(world, bytes.NewReader(data))
+func ReadSnapshotManifest(reader io.Reader) (*ComponentManifest, error) {
+	sr := &snapshotReader{r: reader}
+	components, err := readSnapshotMeta(sr)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ComponentManifest{}
+	for _, c := range components {
+		manifest.Components = append(manifest.Components, c.ComponentManifestEntry)
+	}
+	return manifest, nil
+}
+
+type snapshotComponent struct {
+	ComponentManifestEntry
+	componentType ComponentType
+}
+
+func readSnapshotMeta(sr *snapshotReader) ([]snapshotComponent, error) {
+	if sr.u32() != snapshotMagic {
+		if sr.err != nil {
+			return nil, sr.err
+		}
+		return nil, errors.New("load world: invalid snapshot")
+	}
+	if v := sr.u16(); v != snapshotVersion && sr.err == nil {
+		return nil, fmt.Errorf("load world: unsupported snapshot version %d", v)
+	}
+	count := int(sr.u16())
+	components := make([]snapshotComponent, 0, count)
+	for i := 0; i < count && sr.err == nil; i++ {
+		c := snapshotComponent{}
+		c.ID = sr.u16()
+		c.componentType = ComponentType(sr.u8())
+		c.Name = sr.str()
+		c.Size = sr.u32()
+		c.Layout = uint64(sr.i64())
+		components = append(components, c)
+	}
+	return components, sr.err
+}
+
+// LoadWorld restores a snapshot written by SaveWorld into a freshly initialized world. All
+// component types of the snapshot must be registered in the target world with the ids of the
+// snapshot, usually by binding the manifest of ReadSnapshotManifest and registering the same
+// systems. Stable ids (WorldConfig.StableComponentID) and RegisterComponentWithID need no binding.
+// Relation kinds are matched by type name.
+func LoadWorld(world IWorld, reader io.Reader) error {
+	w := world.base()
+	w.checkMainThread()
+
+	if w.entities.Len() != 0 {
+		return errors.New("load world: target world is not empty")
+	}
+
+	sr := &snapshotReader{r: reader}
+	components, err := readSnapshotMeta(sr)
+	if err != nil {
+		return err
+	}
+
+	// component meta
+	byName := map[string]*ComponentMetaInfo{}
+	for typ, it := range w.componentMeta.types {
+		byName[componentTypeName(typ)] = w.componentMeta.infos.Get(it)
+	}
+	for _, c := range components {
+		info, ok := byName[c.Name]
+		if !ok {
+			return fmt.Errorf("load world: component %s is not registered", c.Name)
+		}
+		if info.it != c.ID {
+			return fmt.Errorf("load world: component %s id changed, snapshot: %d, current: %d, bind the snapshot manifest before registering it",
+				c.Name, c.ID, info.it)
+		}
+		if uint32(info.typ.Size()) != c.Size || componentLayoutHash(info.typ) != c.Layout || info.componentType != c.componentType {
+			return fmt.Errorf("load world: component %s layout changed", c.Name)
+		}
+	}
+
+	// entity id generator
+	g := w.idGenerator
+	idCount := sr.count()
+	if sr.err != nil {
+		return sr.err
+	}
+	ids := make([]RealID, 0, preallocCount(idCount))
+	for i := 0; i < idCount && sr.err == nil; i++ {
+		ids = append(ids, RealID{index: sr.i32(), reuse: sr.i32()})
+	}
+	free := sr.i32()
+	pending := sr.i32()
+	length := sr.i32()
+	delayFree := sr.i32()
+	if sr.err != nil {
+		return sr.err
+	}
+	if free < 0 || free > pending || length < 0 || length > pending || delayFree < 0 || delayFree > pending {
+		return errors.New("load world: invalid entity id generator state")
+	}
+	if delayFree >= g.delayCap {
+		// one free slot, FreeID writes before flushing the delayed ids
+		g.removeDelay = make([]RealID, delayFree+1)
+		g.delayCap = delayFree + 1
+	}
+	for i := int32(0); i < delayFree; i++ {
+		g.removeDelay[i].index = sr.i32()
+		g.removeDelay[i].reuse = sr.i32()
+	}
+	if sr.err != nil {
+		return sr.err
+	}
+	g.ids, g.free, g.pending, g.len, g.delayFree = ids, free, pending, length, delayFree
+
+	// entities
+	entityCount := sr.count()
+	for i := 0; i < entityCount && sr.err == nil; i++ {
+		info := EntityInfo{entity: Entity(sr.i64())}
+		n := int(sr.u16())
+		info.compound = NewCompound(n)
+		for j := 0; j < n; j++ {
+			info.compound.Add(sr.u16())
+		}
+		if sr.err != nil {
+			break
+		}
+		w.archetypes.place(w.addEntity(info))
+	}
+
+	// component sets
+	setCount := int(sr.u16())
+	for i := 0; i < setCount && sr.err == nil; i++ {
+		it := sr.u16()
+		if sr.err != nil {
+			break
+		}
+		meta := w.componentMeta.infos.Get(it)
+		if meta == nil {
+			return errors.New("load world: component set without meta")
+		}
+		w.components.checkSet(reflect.New(meta.typ).Interface().(IComponent))
+		sr.err = w.components.getComponentSetByIntType(it).deserialize(sr.r)
+	}
+
+	// hierarchy and relations, the relation kinds are bound to their types when first used
+	sr.relationSet(w.hierarchy.pairs)
+	relationCount := int(sr.u16())
+	for i := 0; i < relationCount && sr.err == nil; i++ {
+		set := newRelationSet()
+		w.relations.restored[sr.str()] = set
+		sr.relationSet(set)
+	}
+
+	return sr.err
+}
+
+func componentTypeName(typ reflect.Type) string {
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+type snapshotWriter struct {
+	w   io.Writer
+	buf [8]byte
+	err error
+}
+
+func (s *snapshotWriter) write(b []byte) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = s.w.Write(b)
+}
+
+func (s *snapshotWriter) u8(v uint8) {
+	s.buf[0] = v
+	s.write(s.buf[:1])
+}
+
+func (s *snapshotWriter) u16(v uint16) {
+	binary.LittleEndian.PutUint16(s.buf[:2], v)
+	s.write(s.buf[:2])
+}
+
+func (s *snapshotWriter) u32(v uint32) {
+	binary.LittleEndian.PutUint32(s.buf[:4], v)
+	s.write(s.buf[:4])
+}
+
+func (s *snapshotWriter) i32(v int32) {
+	s.u32(uint32(v))
+}
+
+func (s *snapshotWriter) i64(v int64) {
+	binary.LittleEndian.PutUint64(s.buf[:8], uint64(v))
+	s.write(s.buf[:8])
+}
+
+func (s *snapshotWriter) bytes(b []byte) {
+	s.u32(uint32(len(b)))
+	s.write(b)
+}
+
+func (s *snapshotWriter) str(v string) {
+	s.bytes([]byte(v))
+}
+
+// relationSet write the pairs of the set grouped by target, in the order of the sources, e.g. the
+// children of a parent keep their order
+func (s *snapshotWriter) relationSet(set *relationSet) {
+	targets := make([]Entity, 0, len(set.sources))
+	for target := range set.sources {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+	s.i32(int32(len(targets)))
+	for _, target := range targets {
+		sources := set.sources[target]
+		s.i64(int64(target))
+		s.i32(int32(len(sources)))
+		for _, source := range sources {
+			s.i64(int64(source))
+		}
+	}
+}
+
+type snapshotReader struct {
+	r   io.Reader
+	buf [8]byte
+	err error
+}
+
+func (s *snapshotReader) read(b []byte) {
+	if s.err != nil {
+		for i := range b {
+			b[i] = 0
+		}
+		return
+	}
+	_, s.err = io.ReadFull(s.r, b)
+}
+
+func (s *snapshotReader) u8() uint8 {
+	s.read(s.buf[:1])
+	return s.buf[0]
+}
+
+func (s *snapshotReader) u16() uint16 {
+	s.read(s.buf[:2])
+	return binary.LittleEndian.Uint16(s.buf[:2])
+}
+
+func (s *snapshotReader) u32() uint32 {
+	s.read(s.buf[:4])
+	return binary.LittleEndian.Uint32(s.buf[:4])
+}
+
+func (s *snapshotReader) i32() int32 {
+	return int32(s.u32())
+}
+
+func (s *snapshotReader) i64() int64 {
+	s.read(s.buf[:8])
+	return int64(binary.LittleEndian.Uint64(s.buf[:8]))
+}
+
+// count read a non-negative count
+func (s *snapshotReader) count() int {
+	n := s.i32()
+	if n < 0 && s.err == nil {
+		s.err = errSnapshotCount
+	}
+	return int(n)
+}
+
+func (s *snapshotReader) bytes() []byte {
+	n := s.u32()
+	if s.err != nil {
+		return nil
+	}
+	if n > snapshotMaxBytes {
+		s.err = errSnapshotLength
+		return nil
+	}
+	b := make([]byte, n)
+	s.read(b)
+	return b
+}
+
+func (s *snapshotReader) str() string {
+	return string(s.bytes())
+}
+
+func (s *snapshotReader) relationSet(set *relationSet) {
+	count := s.count()
+	for i := 0; i < count && s.err == nil; i++ {
+		target := Entity(s.i64())
+		n := s.count()
+		for j := 0; j < n && s.err == nil; j++ {
+			source := Entity(s.i64())
+			if s.err == nil {
+				set.add(source, target)
+			}
+		}
+	}
+}
+
+func preallocCount(n int) int {
+	if n > snapshotMaxPrealloc {
+		return snapshotMaxPrealloc
+	}
+	return n
+}
+
+func rawBytes[T any](p *T, size uintptr) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(p)), size)
+}
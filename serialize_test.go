@@ -0,0 +1,284 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+type __serialize_Test_C_1 struct {
+	Component[__serialize_Test_C_1]
+	Field1 int
+	Field2 [3]float32
+}
+
+type __serialize_Test_C_2 struct {
+	Component[__serialize_Test_C_2]
+	Name FixedString[Fixed16]
+}
+
+type __serialize_Test_C_3 struct {
+	Component[__serialize_Test_C_3]
+	Field1 int32
+}
+
+func (c *__serialize_Test_C_3) Serialize() []byte {
+	return []byte{byte(c.Field1)}
+}
+
+func (c *__serialize_Test_C_3) DeSerialize(b []byte) {
+	c.Field1 = int32(b[0]) + 1000
+}
+
+type __serialize_Test_S_1 struct {
+	System[__serialize_Test_S_1]
+}
+
+func (s *__serialize_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__serialize_Test_C_1{}, &__serialize_Test_C_2{}, &__serialize_Test_C_3{})
+	return nil
+}
+
+func TestSaveWorld_LoadWorld(t *testing.T) {
+	src := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_1](src)
+	src.Startup()
+
+	var entities []Entity
+	for i := 0; i < 10; i++ {
+		e := src.NewEntity()
+		c2 := &__serialize_Test_C_2{}
+		c2.Name.Set("entity")
+		src.Add(e, &__serialize_Test_C_1{Field1: i, Field2: [3]float32{1, 2, float32(i)}}, c2)
+		if i%2 == 0 {
+			src.Add(e, &__serialize_Test_C_3{Field1: int32(i)})
+		}
+		entities = append(entities, e)
+	}
+	src.Update()
+	src.DestroyEntity(entities[3])
+	src.Update()
+
+	buf := &bytes.Buffer{}
+	if err := SaveWorld(src, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_1](dst)
+	if err := LoadWorld(dst, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.entities.Len() != src.entities.Len() {
+		t.Fatalf("entity count mismatch, want %d, got %d", src.entities.Len(), dst.entities.Len())
+	}
+	set1 := dst.getComponentSet(TypeOf[__serialize_Test_C_1]()).(*ComponentSet[__serialize_Test_C_1])
+	set3 := dst.getComponentSet(TypeOf[__serialize_Test_C_3]()).(*ComponentSet[__serialize_Test_C_3])
+	for i, e := range entities {
+		c1 := set1.Get(e)
+		if i == 3 {
+			if c1 != nil {
+				t.Errorf("destroyed entity %d restored", e)
+			}
+			continue
+		}
+		if c1 == nil || c1.Field1 != i || c1.Field2[2] != float32(i) || c1.Owner() != e {
+			t.Errorf("component mismatch, entity: %d, got: %+v", e, c1)
+		}
+		info, ok := dst.getEntityInfo(e)
+		if !ok || !info.Has(GetComponentMeta[__serialize_Test_C_1](dst).it) {
+			t.Errorf("entity info mismatch, entity: %d", e)
+		}
+		c3 := set3.Get(e)
+		if i%2 == 0 && (c3 == nil || c3.Field1 != int32(i)+1000) {
+			t.Errorf("custom serialize mismatch, entity: %d, got: %+v", e, c3)
+		}
+	}
+
+	if src.NewEntity() != dst.NewEntity() {
+		t.Error("entity id generator state mismatch")
+	}
+}
+
+type __serialize_Test_C_4 struct {
+	Component[__serialize_Test_C_4]
+	Field1 int
+}
+
+type __serialize_Test_S_2 struct {
+	System[__serialize_Test_S_2]
+}
+
+func (s *__serialize_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__serialize_Test_C_4{})
+	return nil
+}
+
+func TestLoadWorld_ComponentID(t *testing.T) {
+	src := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_2](src)
+	RegisterSystem[__serialize_Test_S_1](src)
+	src.Startup()
+	e := src.NewEntity()
+	src.Add(e, &__serialize_Test_C_1{Field1: 7}, &__serialize_Test_C_4{Field1: 8})
+	src.Update()
+
+	buf := &bytes.Buffer{}
+	if err := SaveWorld(src, buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// registered in another order, the ids differ from the snapshot
+	unbound := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_1](unbound)
+	RegisterSystem[__serialize_Test_S_2](unbound)
+	if err := LoadWorld(unbound, bytes.NewReader(data)); err == nil {
+		t.Fatal("snapshot loaded with different component ids")
+	}
+
+	manifest, err := ReadSnapshotManifest(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := NewSyncWorld(NewDefaultWorldConfig())
+	if err := manifest.Bind(dst); err != nil {
+		t.Fatal(err)
+	}
+	RegisterSystem[__serialize_Test_S_1](dst)
+	RegisterSystem[__serialize_Test_S_2](dst)
+	if err := LoadWorld(dst, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportComponentManifest(src).Check(dst); err != nil {
+		t.Fatal(err)
+	}
+	c4 := dst.getComponentSet(TypeOf[__serialize_Test_C_4]()).(*ComponentSet[__serialize_Test_C_4]).Get(e)
+	if c4 == nil || c4.Field1 != 8 || c4.getIntType() != GetComponentMeta[__serialize_Test_C_4](src).it {
+		t.Fatalf("component mismatch, got: %+v", c4)
+	}
+}
+
+func TestLoadWorld_Relations(t *testing.T) {
+	src := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_1](src)
+	src.Startup()
+	root, c1, c2, g1, other := src.NewEntity(), src.NewEntity(), src.NewEntity(), src.NewEntity(), src.NewEntity()
+	for i, e := range []Entity{root, c1, c2, g1, other} {
+		src.Add(e, &__serialize_Test_C_1{Field1: i})
+	}
+	src.SetParent(c1, root)
+	src.SetParent(c2, root)
+	src.SetParent(g1, c1)
+	AddRelation[__relation_Test_Follows](src, c1, other)
+	AddRelation[__relation_Test_Follows](src, c2, other)
+	AddRelation[__relation_Test_MemberOf](src, other, c2)
+	src.Update()
+
+	buf := &bytes.Buffer{}
+	if err := SaveWorld(src, buf); err != nil {
+		t.Fatal(err)
+	}
+	dst := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_1](dst)
+	if err := LoadWorld(dst, buf); err != nil {
+		t.Fatal(err)
+	}
+	dst.Startup()
+
+	children := dst.hierarchy.getChildren(root)
+	if len(children) != 2 || children[0] != c1 || children[1] != c2 {
+		t.Fatalf("children of root, got %v", children)
+	}
+	if parent, ok := dst.hierarchy.getParent(g1); !ok || parent != c1 {
+		t.Fatal("parent of grandchild not restored")
+	}
+	followers := dst.relations.getSet(TypeOf[__relation_Test_Follows]()).sources[other]
+	if len(followers) != 2 || followers[0] != c1 || followers[1] != c2 {
+		t.Fatalf("relation sources, got %v", followers)
+	}
+	// the restored set is bound to its type by the first operation and keeps its pairs
+	AddRelation[__relation_Test_MemberOf](dst, g1, c2)
+	dst.Update()
+	members := dst.relations.getSet(TypeOf[__relation_Test_MemberOf]()).sources[c2]
+	if len(members) != 2 || members[0] != other || members[1] != g1 {
+		t.Fatalf("relation sources after add, got %v", members)
+	}
+
+	// destroying the restored parent cascades to the restored children
+	dst.DestroyEntity(root)
+	dst.Update()
+	for _, e := range []Entity{root, c1, c2, g1} {
+		if _, ok := dst.getEntityInfo(e); ok {
+			t.Fatalf("entity %d not destroyed", e)
+		}
+	}
+	if _, ok := dst.getEntityInfo(other); !ok {
+		t.Fatal("entity without parent destroyed")
+	}
+	if len(dst.hierarchy.pairs.targets) != 0 || len(dst.hierarchy.pairs.sources) != 0 {
+		t.Fatal("hierarchy not cleaned up")
+	}
+	if set := dst.relations.getSet(TypeOf[__relation_Test_Follows]()); len(set.targets) != 0 || len(set.sources) != 0 {
+		t.Fatal("restored relations not cleaned up")
+	}
+	if len(dst.relations.getSet(TypeOf[__relation_Test_MemberOf]()).sources) != 0 {
+		t.Fatal("relations not cleaned up")
+	}
+}
+
+func TestLoadWorld_Corrupted(t *testing.T) {
+	src := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__serialize_Test_S_1](src)
+	src.Startup()
+	for i := 0; i < 3; i++ {
+		src.Add(src.NewEntity(), &__serialize_Test_C_1{Field1: i}, &__serialize_Test_C_3{Field1: int32(i)})
+	}
+	src.Update()
+	buf := &bytes.Buffer{}
+	if err := SaveWorld(src, buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// offsets of the first component name and of the entity id generator
+	nameOffset := 4 + 2 + 2 + 2 + 1
+	idsOffset := 4 + 2 + 2
+	for _, c := range ExportComponentManifest(src).Components {
+		idsOffset += 2 + 1 + 4 + len(c.Name) + 4 + 8
+	}
+	idCount := int(binary.LittleEndian.Uint32(data[idsOffset:]))
+	delayOffset := idsOffset + 4 + idCount*8 + 12
+
+	corrupt := func(offset int, v uint32) []byte {
+		b := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(b[offset:], v)
+		return b
+	}
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"name length", corrupt(nameOffset, 0xffffffff), errSnapshotLength},
+		{"negative id count", corrupt(idsOffset, 0xffffffff), errSnapshotCount},
+		{"id count past the end", corrupt(idsOffset, 0x7fffffff), nil},
+		{"negative delayed ids", corrupt(delayOffset, 0xffffffff), nil},
+		{"truncated", data[:len(data)-3], nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := NewSyncWorld(NewDefaultWorldConfig())
+			RegisterSystem[__serialize_Test_S_1](dst)
+			err := LoadWorld(dst, bytes.NewReader(tt.data))
+			if err == nil {
+				t.Fatal("corrupted snapshot loaded")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("want %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
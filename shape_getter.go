@@ -8,6 +8,7 @@ import (
 type IShape interface {
 	base() *shapeBase
 	getType() reflect.Type
+	getSubTypes() []uint16
 }
 
 type shapeBase struct {
@@ -32,6 +33,72 @@ type ShapeIndices struct {
 	subOffset  []uintptr
 	containers []IComponentSet
 	readOnly   []bool
+	optional   []bool
+	exclude    []uint16
+	entitySet  *EntitySet
+	entities   []Entity
+}
+
+// Optional shape field, Get returns nil when the entity has no C, e.g.
+//
+//	type MoveShape struct {
+//		pos    *Position
+//		health ecs.Optional[Health]
+//	}
+//
+// same as a *C field tagged `ecs:"optional"`
+type Optional[C ComponentObject] struct {
+	com *C
+}
+
+func (o Optional[C]) Get() *C {
+	return o.com
+}
+
+func (o Optional[C]) shapeField() (shapeFieldKind, reflect.Type) {
+	return shapeFieldOptional, TypeOf[C]()
+}
+
+// Without shape field skipping entities which have C, same as a *C field tagged `ecs:"without"`
+type Without[C ComponentObject] struct{}
+
+func (w Without[C]) shapeField() (shapeFieldKind, reflect.Type) {
+	return shapeFieldWithout, TypeOf[C]()
+}
+
+type shapeFieldKind uint8
+
+const (
+	shapeFieldRequired shapeFieldKind = iota
+	shapeFieldOptional
+	shapeFieldWithout
+	shapeFieldChanged
+	shapeFieldAdded
+)
+
+type iShapeField interface {
+	shapeField() (shapeFieldKind, reflect.Type)
+}
+
+func parseShapeField(field reflect.StructField) (shapeFieldKind, reflect.Type, bool) {
+	if field.Type.Implements(reflect.TypeOf((*iShapeField)(nil)).Elem()) {
+		kind, typ := reflect.Zero(field.Type).Interface().(iShapeField).shapeField()
+		return kind, typ, true
+	}
+	if field.Type.Kind() != reflect.Pointer || !field.Type.Implements(reflect.TypeOf((*IComponent)(nil)).Elem()) {
+		return 0, nil, false
+	}
+	switch field.Tag.Get("ecs") {
+	case "optional":
+		return shapeFieldOptional, field.Type.Elem(), true
+	case "without":
+		return shapeFieldWithout, field.Type.Elem(), true
+	case "changed":
+		return shapeFieldChanged, field.Type.Elem(), true
+	case "added":
+		return shapeFieldAdded, field.Type.Elem(), true
+	}
+	return shapeFieldRequired, field.Type.Elem(), true
 }
 
 type Shape[T any] struct {
@@ -42,8 +109,16 @@ type Shape[T any] struct {
 	subOffset    []uintptr
 	containers   []IComponentSet
 	readOnly     []bool
+	optional     []bool
+	exclude      []uint16
 	cur          *T
 	valid        bool
+	filterIndex  int
+	filterKind   changeKind
+	entities     []Entity
+	tables       []shapeTable
+	tableVersion uint64
+	tableMatched bool
 }
 
 func NewShape[T any](initializer SystemInitConstraint) *Shape[T] {
@@ -52,8 +127,10 @@ func NewShape[T any](initializer SystemInitConstraint) *Shape[T] {
 	}
 	sys := initializer.getSystem()
 	getter := &Shape[T]{
-		shapeBase:   shapeBase{sys: sys},
-		initializer: initializer,
+		shapeBase:    shapeBase{sys: sys},
+		initializer:  initializer,
+		mainKeyIndex: -1,
+		filterIndex:  -1,
 	}
 
 	typ := reflect.TypeOf(getter)
@@ -66,26 +143,52 @@ func NewShape[T any](initializer SystemInitConstraint) *Shape[T] {
 
 	getter.cur = new(T)
 	typIns := reflect.TypeOf(*getter.cur)
+	required := 0
 	for i := 0; i < typIns.NumField(); i++ {
 		field := typIns.Field(i)
-		if !field.Type.Implements(reflect.TypeOf((*IComponent)(nil)).Elem()) || !sys.isRequire(field.Type.Elem()) {
+		kind, comTyp, ok := parseShapeField(field)
+		if !ok {
+			continue
+		}
+		if kind == shapeFieldWithout {
+			// no access to the data, only the type is needed
+			ins := reflect.New(comTyp).Interface().(IComponent)
+			getter.exclude = append(getter.exclude, sys.World().getOrCreateComponentMetaInfo(ins).it)
 			continue
 		}
-		if r, ok := sysReq[field.Type.Elem()]; ok {
-			if r.getPermission() == ComponentReadOnly {
-				getter.readOnly = append(getter.readOnly, true)
-			} else {
-				getter.readOnly = append(getter.readOnly, false)
+		if !sys.isRequire(comTyp) {
+			continue
+		}
+		if sysReq[comTyp].getPermission() == ComponentReadOnly {
+			getter.readOnly = append(getter.readOnly, true)
+		} else {
+			getter.readOnly = append(getter.readOnly, false)
+		}
+		meta := sys.World().getComponentMetaInfoByType(comTyp)
+		switch kind {
+		case shapeFieldChanged, shapeFieldAdded:
+			// iterates only entities whose component changed since the system last ran
+			if getter.filterIndex >= 0 {
+				panic("only one filter field is allowed in a shape")
 			}
+			getter.filterIndex = len(getter.subTypes)
+			getter.filterKind = changeKindAdded
+			if kind == shapeFieldChanged {
+				getter.filterKind |= changeKindModified
+			}
+			sys.World().getComponentSet(comTyp).trackChanges(sys)
+		}
+		getter.optional = append(getter.optional, kind == shapeFieldOptional)
+		if kind != shapeFieldOptional {
+			required++
 		}
-		meta := sys.World().getComponentMetaInfoByType(field.Type.Elem())
 		getter.subTypes = append(getter.subTypes, meta.it)
 		getter.subOffset = append(getter.subOffset, field.Offset)
 	}
 
 	getter.containers = make([]IComponentSet, len(getter.subTypes))
 
-	if len(getter.subTypes) == 0 {
+	if required == 0 {
 		return nil
 	}
 
@@ -105,6 +208,17 @@ func (s *Shape[T]) getType() reflect.Type {
 	return s.typ
 }
 
+// getSubTypes required component types of the shape
+func (s *Shape[T]) getSubTypes() []uint16 {
+	var its []uint16
+	for i, it := range s.subTypes {
+		if !s.optional[i] {
+			its = append(its, it)
+		}
+	}
+	return its
+}
+
 func (s *Shape[T]) Get() IShapeIterator[T] {
 	s.executeNum++
 
@@ -116,6 +230,10 @@ func (s *Shape[T]) Get() IShapeIterator[T] {
 	var mainKeyIndex int
 	for i := 0; i < len(s.subTypes); i++ {
 		c := s.sys.World().getComponentSetByIntType(s.subTypes[i])
+		s.containers[i] = c
+		if s.optional[i] {
+			continue
+		}
 		if c == nil || c.Len() == 0 {
 			return EmptyShapeIter[T]()
 		}
@@ -123,31 +241,97 @@ func (s *Shape[T]) Get() IShapeIterator[T] {
 			mainComponent = c
 			mainKeyIndex = i
 		}
-		s.containers[i] = c
 	}
 
-	if s.mainKeyIndex == 0 {
+	if s.mainKeyIndex >= 0 {
 		mainKeyIndex = s.mainKeyIndex
-		mainComponent = s.containers[mainKeyIndex]
 	}
 
-	return NewShapeIterator[T](
-		ShapeIndices{
-			subTypes:   s.subTypes,
-			subOffset:  s.subOffset,
-			containers: s.containers,
-			readOnly:   s.readOnly,
-		},
-		mainKeyIndex)
+	indices := ShapeIndices{
+		subTypes:   s.subTypes,
+		subOffset:  s.subOffset,
+		containers: s.containers,
+		readOnly:   s.readOnly,
+		optional:   s.optional,
+		exclude:    s.exclude,
+	}
+	if len(s.exclude) > 0 {
+		indices.entitySet = s.sys.World().base().entities
+	}
+	if s.filterIndex >= 0 {
+		since, until := s.sys.changeWindow()
+		s.entities = s.containers[s.filterIndex].getChangeLog().collect(s.filterKind, since, until, s.entities[:0])
+		if len(s.entities) == 0 {
+			return EmptyShapeIter[T]()
+		}
+		indices.entities = s.entities
+	}
+
+	if storage := s.sys.World().base().archetypes; storage != nil && s.filterIndex < 0 {
+		if s.tableVersion != storage.version {
+			s.tables, s.tableMatched = matchShapeTables(storage, indices, s.tables[:0])
+			s.tableVersion = storage.version
+		}
+		if s.tableMatched {
+			if len(s.tables) == 0 {
+				return EmptyShapeIter[T]()
+			}
+			return newArchetypeShapeIter[T](indices, s.tables)
+		}
+	}
+
+	return NewShapeIterator[T](indices, mainKeyIndex)
+}
+
+// getByEntities iterate the shape over an entity list
+func (s *Shape[T]) getByEntities(entities []Entity) IShapeIterator[T] {
+	s.executeNum++
+
+	if !s.valid || len(entities) == 0 {
+		return EmptyShapeIter[T]()
+	}
+	for i := 0; i < len(s.subTypes); i++ {
+		c := s.sys.World().getComponentSetByIntType(s.subTypes[i])
+		s.containers[i] = c
+		if !s.optional[i] && (c == nil || c.Len() == 0) {
+			return EmptyShapeIter[T]()
+		}
+	}
+	indices := ShapeIndices{
+		subTypes:   s.subTypes,
+		subOffset:  s.subOffset,
+		containers: s.containers,
+		readOnly:   s.readOnly,
+		optional:   s.optional,
+		exclude:    s.exclude,
+		entities:   entities,
+	}
+	if len(s.exclude) > 0 {
+		indices.entitySet = s.sys.World().base().entities
+	}
+	return NewShapeIterator[T](indices, 0)
 }
 
 func (s *Shape[T]) GetSpecific(entity Entity) (*T, bool) {
 	if !s.valid {
 		return s.cur, false
 	}
+	if len(s.exclude) > 0 {
+		info, ok := s.sys.World().base().entities.GetEntityInfo(entity)
+		if !ok || info.compound.ExistAny(s.exclude) {
+			return s.cur, false
+		}
+	}
 	for i := 0; i < len(s.subTypes); i++ {
-		subPointer := s.containers[i].getPointerByEntity(entity)
+		var subPointer unsafe.Pointer
+		if s.containers[i] != nil {
+			subPointer = s.containers[i].getPointerByEntity(entity)
+		}
 		if subPointer == nil {
+			if s.optional[i] {
+				*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.subOffset[i])) = nil
+				continue
+			}
 			return s.cur, false
 		}
 		if s.readOnly[i] {
@@ -162,7 +346,7 @@ func (s *Shape[T]) GetSpecific(entity Entity) (*T, bool) {
 func (s *Shape[T]) SetGuide(component IComponent) *Shape[T] {
 	meta := s.initializer.getSystem().World().getComponentMetaInfoByType(component.Type())
 	for i, r := range s.subTypes {
-		if r == meta.it {
+		if r == meta.it && !s.optional[i] {
 			s.mainKeyIndex = i
 			return s
 		}
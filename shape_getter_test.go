@@ -15,6 +15,11 @@ type __ShapeGetter_Test_C_2 struct {
 	Field1 int
 }
 
+type __ShapeGetter_Test_C_3 struct {
+	Component[__ShapeGetter_Test_C_3]
+	Field1 int
+}
+
 type __ShapeGetter_Test_Shape_1 struct {
 	c1 *__ShapeGetter_Test_C_1
 	c2 *__ShapeGetter_Test_C_2
@@ -26,13 +31,14 @@ type __ShapeGetter_Test_S_1 struct {
 	getter1 *Shape[__ShapeGetter_Test_Shape_1]
 }
 
-func (t *__ShapeGetter_Test_S_1) Init(initializer SystemInitConstraint) {
+func (t *__ShapeGetter_Test_S_1) Init(initializer SystemInitConstraint) error {
 	t.SetRequirements(initializer, &__ShapeGetter_Test_C_1{}, &__ShapeGetter_Test_C_2{})
 
 	t.getter1 = NewShape[__ShapeGetter_Test_Shape_1](initializer)
 	if t.getter1 == nil {
 		initializer.SetBroken("invalid getter")
 	}
+	return nil
 }
 
 func (t *__ShapeGetter_Test_S_1) Update(event Event) {
@@ -43,6 +49,86 @@ func (t *__ShapeGetter_Test_S_1) Update(event Event) {
 	}
 }
 
+type __ShapeGetter_Test_Shape_2 struct {
+	c1 *__ShapeGetter_Test_C_1
+	c2 *__ShapeGetter_Test_C_2 `ecs:"optional"`
+	c3 Without[__ShapeGetter_Test_C_3]
+}
+
+type __ShapeGetter_Test_Shape_3 struct {
+	c1 *__ShapeGetter_Test_C_1 `ecs:"without"`
+	c2 Optional[__ShapeGetter_Test_C_2]
+	c3 *__ShapeGetter_Test_C_3
+}
+
+type __ShapeGetter_Test_S_2 struct {
+	System[__ShapeGetter_Test_S_2]
+
+	getter2 *Shape[__ShapeGetter_Test_Shape_2]
+	getter3 *Shape[__ShapeGetter_Test_Shape_3]
+	result2 map[int]int
+	result3 map[int]int
+}
+
+func (t *__ShapeGetter_Test_S_2) Init(initializer SystemInitConstraint) error {
+	t.SetRequirements(initializer, &__ShapeGetter_Test_C_1{}, &ReadOnly[__ShapeGetter_Test_C_2]{}, &__ShapeGetter_Test_C_3{})
+	t.getter2 = NewShape[__ShapeGetter_Test_Shape_2](initializer)
+	t.getter3 = NewShape[__ShapeGetter_Test_Shape_3](initializer)
+	return nil
+}
+
+func (t *__ShapeGetter_Test_S_2) Update(event Event) {
+	t.result2, t.result3 = map[int]int{}, map[int]int{}
+	iter2 := t.getter2.Get()
+	for s := iter2.Begin(); !iter2.End(); s = iter2.Next() {
+		t.result2[s.c1.Field1] = -1
+		if s.c2 != nil {
+			t.result2[s.c1.Field1] = s.c2.Field1
+		}
+	}
+	iter3 := t.getter3.Get()
+	for s := iter3.Begin(); !iter3.End(); s = iter3.Next() {
+		t.result3[s.c3.Field1] = -1
+		if s.c2.Get() != nil {
+			t.result3[s.c3.Field1] = s.c2.Get().Field1
+		}
+	}
+}
+
+func TestShape_OptionalWithout(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__ShapeGetter_Test_S_2](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__ShapeGetter_Test_S_2]())
+	sys := s.(*__ShapeGetter_Test_S_2)
+
+	// 0: c1 c2, 1: c1, 2: c1 c3, 3: c2 c3, 4: c3
+	e := world.NewEntity()
+	world.Add(e, &__ShapeGetter_Test_C_1{Field1: 0}, &__ShapeGetter_Test_C_2{Field1: 10})
+	e = world.NewEntity()
+	world.Add(e, &__ShapeGetter_Test_C_1{Field1: 1})
+	e = world.NewEntity()
+	world.Add(e, &__ShapeGetter_Test_C_1{Field1: 2}, &__ShapeGetter_Test_C_3{Field1: 2})
+	e = world.NewEntity()
+	world.Add(e, &__ShapeGetter_Test_C_2{Field1: 30}, &__ShapeGetter_Test_C_3{Field1: 3})
+	e = world.NewEntity()
+	world.Add(e, &__ShapeGetter_Test_C_3{Field1: 4})
+	world.Update()
+
+	check := func(name string, got, want map[int]int) {
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", name, got, want)
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Fatalf("%s: got %v, want %v", name, got, want)
+			}
+		}
+	}
+	check("shape2", sys.result2, map[int]int{0: 10, 1: -1})
+	check("shape3", sys.result3, map[int]int{3: 30, 4: -1})
+}
+
 func TestNewShapeGetter(t *testing.T) {
 	world := NewSyncWorld(NewDefaultWorldConfig())
 	RegisterSystem[__ShapeGetter_Test_S_1](world)
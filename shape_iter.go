@@ -29,6 +29,11 @@ func NewShapeIterator[T any](indices ShapeIndices, mainKeyIndex int) IShapeItera
 		mainKeyIndex: mainKeyIndex,
 		offset:       0,
 	}
+	// iterate by entity list, all fields are looked up by entity
+	if indices.entities != nil {
+		iter.maxLen = len(indices.entities)
+		iter.mainKeyIndex = -1
+	}
 
 	return iter
 }
@@ -40,15 +45,16 @@ func (s *ShapeIter[T]) tryNext() *T {
 	var p unsafe.Pointer
 	var ec *EmptyComponent
 	for i := s.offset; i < s.maxLen; i++ {
-		//TODO check if this is the best way to do this
-		p = s.indices.containers[s.mainKeyIndex].getPointerByIndex(int64(s.offset))
-		ec = (*EmptyComponent)(p)
-		if s.indices.readOnly[s.mainKeyIndex] {
-			*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[s.mainKeyIndex])) = &(*(*byte)(p))
+		var entity Entity
+		if s.mainKeyIndex < 0 {
+			entity = s.indices.entities[i]
 		} else {
-			*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[s.mainKeyIndex])) = (*byte)(p)
+			//TODO check if this is the best way to do this
+			p = s.indices.containers[s.mainKeyIndex].getPointerByIndex(int64(i))
+			ec = (*EmptyComponent)(p)
+			s.trans(s.mainKeyIndex, p)
+			entity = ec.Owner()
 		}
-		entity := ec.Owner()
 		skip = s.getSiblings(entity)
 		if !skip {
 			s.offset = i
@@ -64,12 +70,26 @@ func (s *ShapeIter[T]) tryNext() *T {
 }
 
 func (s *ShapeIter[T]) getSiblings(entity Entity) bool {
+	// fast rejection by the compound of the entity
+	if s.indices.entitySet != nil {
+		info, ok := s.indices.entitySet.GetEntityInfo(entity)
+		if !ok || info.compound.ExistAny(s.indices.exclude) {
+			return true
+		}
+	}
 	for i := 0; i < len(s.indices.subTypes); i++ {
 		if i == s.mainKeyIndex {
 			continue
 		}
-		subPointer := s.indices.containers[i].getPointerByEntity(entity)
+		var subPointer unsafe.Pointer
+		if s.indices.containers[i] != nil {
+			subPointer = s.indices.containers[i].getPointerByEntity(entity)
+		}
 		if subPointer == nil {
+			if s.indices.optional[i] {
+				*(**byte)(unsafe.Add(unsafe.Pointer(s.cur), s.indices.subOffset[i])) = nil
+				continue
+			}
 			return true
 		}
 		s.trans(i, subPointer)
@@ -93,8 +113,8 @@ func (s *ShapeIter[T]) End() bool {
 }
 
 func (s *ShapeIter[T]) Begin() *T {
-	if s.maxLen != 0 {
-		s.offset = 0
+	if s.maxLen > s.begin {
+		s.offset = s.begin
 		s.cur = new(T)
 		s.tryNext()
 	}
@@ -117,3 +137,16 @@ func (s *ShapeIter[T]) Next() *T {
 	}
 	return s.cur
 }
+
+func (s *ShapeIter[T]) rangeLen() int {
+	return s.maxLen - s.begin
+}
+
+func (s *ShapeIter[T]) subRange(begin int, end int) Iterator[T] {
+	sub := *s
+	sub.begin = s.begin + begin
+	sub.maxLen = s.begin + end
+	sub.offset = sub.begin
+	sub.cur = nil
+	return &sub
+}
@@ -44,18 +44,22 @@ func NewSparseArray[K Integer, V any](initSize ...int) *SparseArray[K, V] {
 func (g *SparseArray[K, V]) Add(key K, value *V) *V {
 	length := len(g.indices)
 	// already existed
-	if key < K(length) && g.indices[key] != 0 {
+	if int(key) < length && g.indices[key] != 0 {
 		return nil
 	}
 	_, idx := g.UnorderedCollection.Add(value)
-	if key >= K(length) {
-		m := K(0)
-		if length == 0 {
-			m = key + 1
-		} else if length < int(g.shrinkThreshold) {
-			m = key * 2
-		} else {
-			m = key * 5 / 4
+	if int(key) >= length {
+		// grow in int, key*2 overflows small key types
+		m := int(key) + 1
+		if length != 0 {
+			if length < int(g.shrinkThreshold) {
+				m = int(key) * 2
+			} else {
+				m = int(key) * 5 / 4
+			}
+		}
+		if m <= int(key) {
+			m = int(key) + 1
 		}
 		newIndices := make([]int32, m)
 		count := copy(newIndices, g.indices)
@@ -75,7 +79,7 @@ func (g *SparseArray[K, V]) Add(key K, value *V) *V {
 }
 
 func (g *SparseArray[K, V]) Remove(key K) *V {
-	if key > g.maxKey {
+	if key > g.maxKey || int(key) >= len(g.indices) || g.indices[key] == 0 {
 		return nil
 	}
 	idx := g.indices[key] - 1
@@ -93,14 +97,14 @@ func (g *SparseArray[K, V]) Remove(key K) *V {
 }
 
 func (g *SparseArray[K, V]) Exist(key K) bool {
-	if key > g.maxKey {
+	if key > g.maxKey || int(key) >= len(g.indices) {
 		return false
 	}
 	return !(g.indices[key] == 0)
 }
 
 func (g *SparseArray[K, V]) Get(key K) *V {
-	if key > g.maxKey {
+	if key > g.maxKey || int(key) >= len(g.indices) {
 		return nil
 	}
 	idx := g.indices[key] - 1
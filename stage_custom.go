@@ -0,0 +1,108 @@
+package ecs
+
+import (
+	"sync"
+)
+
+// StageMode execute mode of a custom stage
+type StageMode uint8
+
+const (
+	StageModeParallel StageMode = iota // systems of a batch run in parallel on the worker pool
+	StageModeSync                      // systems run one by one on the main thread
+)
+
+const stageCustomBegin Stage = 1000
+
+type stageDesc struct {
+	name string
+	mode StageMode
+}
+
+// custom stages are shared by all worlds, a world runs the ones inserted into it
+var customStages = struct {
+	sync.RWMutex
+	next  Stage
+	descs map[Stage]stageDesc
+}{
+	next:  stageCustomBegin,
+	descs: map[Stage]stageDesc{},
+}
+
+// StageReceiver receiver of the custom stages joined in Init
+type StageReceiver interface {
+	RunStage(stage Stage, event Event)
+}
+
+// NewStage define a custom stage, usually in a package level var or func init(){}, e.g.
+//
+//	var StagePhysics = ecs.NewStage("Physics", ecs.StageModeParallel)
+func NewStage(name string, mode StageMode) Stage {
+	customStages.Lock()
+	defer customStages.Unlock()
+	stage := customStages.next
+	customStages.next++
+	customStages.descs[stage] = stageDesc{name: name, mode: mode}
+	return stage
+}
+
+func getCustomStage(stage Stage) (stageDesc, bool) {
+	customStages.RLock()
+	defer customStages.RUnlock()
+	desc, ok := customStages.descs[stage]
+	return desc, ok
+}
+
+func isCustomStage(stage Stage) bool {
+	return stage >= stageCustomBegin
+}
+
+// InsertStage insert a custom stage into the world after the given stage in world init, custom
+// stages run in the update phase, from after StageSyncAfterStart to StageSyncAfterPostUpdate
+func InsertStage(world IWorld, stage Stage, after Stage) {
+	world.base().systemFlow.insertStage(stage, after)
+}
+
+// JoinStage the system runs in the custom stage, the system must implement StageReceiver
+func JoinStage(initializer SystemInitConstraint, stage Stage) {
+	getInitSystem(initializer).joinStage(stage)
+}
+
+func (p *systemFlow) insertStage(stage Stage, after Stage) {
+	if p.world.getStatus() != WorldStatusInitialized {
+		panic("stage insert only in world init")
+	}
+	if _, ok := getCustomStage(stage); !ok {
+		panic("stage is not defined by NewStage")
+	}
+	if !isCustomStage(after) && (after < StageSyncAfterStart || after > StageSyncAfterPostUpdate) {
+		panic("custom stage must be in the update phase")
+	}
+	if _, ok := p.stages[stage]; ok {
+		panic("stage already inserted")
+	}
+	index := -1
+	for i, s := range p.stageList {
+		if s == after {
+			index = i + 1
+			break
+		}
+	}
+	if index < 0 {
+		panic("anchor stage not found")
+	}
+	p.stageList = append(p.stageList[:index], append([]Stage{stage}, p.stageList[index:]...)...)
+
+	sgFront := NewSystemGroup()
+	sgFront.order = OrderFront
+	sgAppend := NewSystemGroup()
+	sgAppend.order = OrderAppend
+	p.stages[stage] = SystemGroupList{sgFront, sgAppend}
+
+	// systems registered before the stage is inserted
+	for _, sys := range p.ordered {
+		if p.isImpEvent(sys, stage) {
+			p.insertToStage(sys, stage, sys.Order())
+		}
+	}
+}
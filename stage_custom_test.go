@@ -0,0 +1,108 @@
+package ecs
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	__stageCustom_Test_Physics     = NewStage("Physics", StageModeParallel)
+	__stageCustom_Test_NetworkSend = NewStage("NetworkSend", StageModeSync)
+)
+
+type __stageCustom_Test_C_1 struct {
+	Component[__stageCustom_Test_C_1]
+}
+
+type __stageCustom_Test_S_1 struct {
+	System[__stageCustom_Test_S_1]
+
+	lock  *sync.Mutex
+	calls *[]string
+}
+
+func (s *__stageCustom_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__stageCustom_Test_C_1{})
+	JoinStage(si, __stageCustom_Test_Physics)
+	return nil
+}
+
+func (s *__stageCustom_Test_S_1) call(name string) {
+	s.lock.Lock()
+	*s.calls = append(*s.calls, name)
+	s.lock.Unlock()
+}
+
+func (s *__stageCustom_Test_S_1) PreUpdate(event Event) {
+	s.call("PreUpdate")
+}
+
+func (s *__stageCustom_Test_S_1) Update(event Event) {
+	s.call("Update")
+}
+
+func (s *__stageCustom_Test_S_1) RunStage(stage Stage, event Event) {
+	s.call(stage.String())
+}
+
+type __stageCustom_Test_S_2 struct {
+	System[__stageCustom_Test_S_2]
+
+	s1 *__stageCustom_Test_S_1
+}
+
+func (s *__stageCustom_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__stageCustom_Test_C_1]{})
+	JoinStage(si, __stageCustom_Test_NetworkSend)
+	return nil
+}
+
+func (s *__stageCustom_Test_S_2) PostUpdate(event Event) {
+	s.s1.call("PostUpdate")
+}
+
+func (s *__stageCustom_Test_S_2) RunStage(stage Stage, event Event) {
+	if !s.isThreadSafe() {
+		s.s1.call("not on main thread")
+	}
+	s.s1.call(stage.String())
+}
+
+func TestInsertStage(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__stageCustom_Test_S_1](world)
+	InsertStage(world, __stageCustom_Test_Physics, StageSyncAfterPreUpdate)
+	RegisterSystem[__stageCustom_Test_S_2](world)
+	InsertStage(world, __stageCustom_Test_NetworkSend, StageSyncAfterPostUpdate)
+	world.Startup()
+
+	s, _ := world.getSystem(TypeOf[__stageCustom_Test_S_1]())
+	s1 := s.(*__stageCustom_Test_S_1)
+	s1.lock = &sync.Mutex{}
+	s1.calls = &[]string{}
+	s, _ = world.getSystem(TypeOf[__stageCustom_Test_S_2]())
+	s.(*__stageCustom_Test_S_2).s1 = s1
+
+	world.Update()
+	world.Update()
+	want := "PreUpdate Physics Update PostUpdate NetworkSend "
+	if got := strings.Join(*s1.calls, " ") + " "; got != want+want {
+		t.Fatalf("stage order, got %s", got)
+	}
+
+	schedule := world.ExportSchedule()
+	if len(schedule.Stages) != 5 || schedule.Stages[1].Stage != "Physics" {
+		t.Fatalf("custom stage in schedule, got %+v", schedule.Stages)
+	}
+}
+
+func TestInsertStage_outOfUpdatePhase(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	defer func() {
+		if recover() == nil {
+			t.Fatal("custom stage in the destroy phase")
+		}
+	}()
+	InsertStage(world, __stageCustom_Test_Physics, StageDestroy)
+}
@@ -66,6 +66,19 @@ type ISystem interface {
 	setBroken()
 	isValid() bool
 	setUtility(u IUtility)
+	markRun(frame uint64)
+	changeWindow() (since uint64, until uint64)
+	nextChangeSince() uint64
+	getSchedule() *systemSchedule
+	getTiming() *systemTiming
+	getFailure() *systemFailure
+	setEventAccess(typ reflect.Type, permission ComponentPermission)
+	getEventAccess() map[reflect.Type]ComponentPermission
+	getCommandBuffer() *CommandBuffer
+	getConstraints() *systemConstraints
+	getParallelCosts() map[reflect.Type]float64
+	joinStage(stage Stage)
+	isInStage(stage Stage) bool
 }
 
 type SystemObject interface {
@@ -96,6 +109,18 @@ type System[T SystemObject] struct {
 	isSafe            bool
 	executing         bool
 	id                int64
+	hasRun            bool
+	runFrame          uint64
+	changeSince       uint64
+	schedule          systemSchedule
+	timing            systemTiming
+	failure           systemFailure
+	events            map[reflect.Type]ComponentPermission
+	commands          *CommandBuffer
+	constraints       systemConstraints
+	parallelCosts     map[reflect.Type]float64
+	stages            []Stage
+	logger            *worldLogger
 }
 
 func (s *System[T]) instance() (sys ISystem) {
@@ -144,6 +169,12 @@ func (s *System[T]) setRequirements(initializer SystemInitConstraint, rqs ...IRe
 	for _, value := range rqs {
 		typ = value.Type()
 		value.check(initializer)
+		// a filter only adds read-only access, it does not override a declared requirement
+		if _, ok := value.(IFilterRequirement); ok {
+			if _, exist := s.requirements[typ]; exist {
+				continue
+			}
+		}
 		s.requirements[typ] = value
 		s.World().getComponentMetaInfoByType(typ)
 	}
@@ -222,11 +253,20 @@ func (s *System[T]) isRequire(typ reflect.Type) bool {
 func (s *System[T]) baseInit(world *ecsWorld, ins ISystem) {
 	s.requirements = map[reflect.Type]IRequirement{}
 	s.getterCache = NewGetterCache(len(s.requirements))
+	// declarations of a previous Init, when the system is restarted
+	s.events = nil
+	s.constraints = systemConstraints{}
+	s.stages = nil
 
 	if ins.Order() == OrderInvalid {
 		s.setOrder(OrderDefault)
 	}
 	s.world = world
+	s.logger = world.logger.forSystem(ins)
+	if s.commands == nil {
+		// allocated before the system runs, Commands is called from parallel goroutines
+		s.commands = &CommandBuffer{}
+	}
 
 	s.valid = true
 
@@ -238,7 +278,7 @@ func (s *System[T]) baseInit(world *ecsWorld, ins ISystem) {
 			return i.Init(initializer)
 		})
 		if err != nil {
-			Log.Error(err)
+			s.logger.Log(LogLevelError, "system init failed", "error", err)
 		}
 	}
 	*initializer.sys = nil
@@ -247,6 +287,99 @@ func (s *System[T]) baseInit(world *ecsWorld, ins ISystem) {
 	s.state = SystemStateStart
 }
 
+// markRun opens the change detection window of the frame on the first run in it
+func (s *System[T]) markRun(frame uint64) {
+	if s.hasRun && s.runFrame == frame {
+		return
+	}
+	if s.hasRun {
+		s.changeSince = s.runFrame + 1
+	}
+	s.hasRun = true
+	s.runFrame = frame
+}
+
+// changeWindow frames of the changes visible to Changed/Added/Removed filters in this run
+func (s *System[T]) changeWindow() (uint64, uint64) {
+	return s.changeSince, s.runFrame
+}
+
+func (s *System[T]) nextChangeSince() uint64 {
+	if !s.hasRun {
+		return 0
+	}
+	return s.runFrame + 1
+}
+
+// setEventAccess writing an event type overrides reading it
+func (s *System[T]) setEventAccess(typ reflect.Type, permission ComponentPermission) {
+	if s.events == nil {
+		s.events = map[reflect.Type]ComponentPermission{}
+	}
+	if p, ok := s.events[typ]; ok && p == ComponentReadWrite {
+		return
+	}
+	s.events[typ] = permission
+}
+
+func (s *System[T]) getEventAccess() map[reflect.Type]ComponentPermission {
+	return s.events
+}
+
+// Commands get the command buffer of the system, usable in any stage and from any goroutine
+func (s *System[T]) Commands() *CommandBuffer {
+	return s.commands
+}
+
+func (s *System[T]) getCommandBuffer() *CommandBuffer {
+	return s.commands
+}
+
+func (s *System[T]) getConstraints() *systemConstraints {
+	return &s.constraints
+}
+
+// getParallelCosts measured cost per element of ParallelForEach, by element type
+func (s *System[T]) getParallelCosts() map[reflect.Type]float64 {
+	if s.parallelCosts == nil {
+		s.parallelCosts = map[reflect.Type]float64{}
+	}
+	return s.parallelCosts
+}
+
+func (s *System[T]) joinStage(stage Stage) {
+	if !s.isInStage(stage) {
+		s.stages = append(s.stages, stage)
+	}
+}
+
+// isInStage whether the system joined the custom stage
+func (s *System[T]) isInStage(stage Stage) bool {
+	for _, st := range s.stages {
+		if st == stage {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *System[T]) getSchedule() *systemSchedule {
+	return &s.schedule
+}
+
+func (s *System[T]) getFailure() *systemFailure {
+	return &s.failure
+}
+
+// Logger the logger of the world, the system name is attached to the logs
+func (s *System[T]) Logger() FieldLogger {
+	return s.logger
+}
+
+func (s *System[T]) getTiming() *systemTiming {
+	return &s.timing
+}
+
 func (s *System[T]) getPointer() unsafe.Pointer {
 	return unsafe.Pointer(s)
 }
@@ -0,0 +1,194 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type systemConstraintTarget struct {
+	typ   reflect.Type
+	label string
+}
+
+func (t systemConstraintTarget) match(sys ISystem) bool {
+	if t.typ != nil {
+		return sys.Type() == t.typ
+	}
+	for _, label := range sys.getConstraints().labels {
+		if label == t.label {
+			return true
+		}
+	}
+	return false
+}
+
+// systemConstraints explicit ordering of a system inside a stage, declared in Init
+type systemConstraints struct {
+	labels []string
+	before []systemConstraintTarget
+	after  []systemConstraintTarget
+}
+
+func getInitSystem(initializer SystemInitConstraint) ISystem {
+	if initializer.isValid() {
+		panic("out of initialization stage")
+	}
+	return initializer.getSystem()
+}
+
+// RunBefore the system runs before system T in the stages both implement
+func RunBefore[T SystemObject](initializer SystemInitConstraint) {
+	c := getInitSystem(initializer).getConstraints()
+	c.before = append(c.before, systemConstraintTarget{typ: TypeOf[T]()})
+}
+
+// RunAfter the system runs after system T in the stages both implement
+func RunAfter[T SystemObject](initializer SystemInitConstraint) {
+	c := getInitSystem(initializer).getConstraints()
+	c.after = append(c.after, systemConstraintTarget{typ: TypeOf[T]()})
+}
+
+// InSet add the system to the named set, sets are targets of RunBeforeSet and RunAfterSet
+func InSet(initializer SystemInitConstraint, label string) {
+	c := getInitSystem(initializer).getConstraints()
+	c.labels = append(c.labels, label)
+}
+
+// RunBeforeSet the system runs before all systems of the named set
+func RunBeforeSet(initializer SystemInitConstraint, label string) {
+	c := getInitSystem(initializer).getConstraints()
+	c.before = append(c.before, systemConstraintTarget{label: label})
+}
+
+// RunAfterSet the system runs after all systems of the named set
+func RunAfterSet(initializer SystemInitConstraint, label string) {
+	c := getInitSystem(initializer).getConstraints()
+	c.after = append(c.after, systemConstraintTarget{label: label})
+}
+
+// precedes whether system a must run before system b
+func precedes(a ISystem, b ISystem) bool {
+	if a == b {
+		return false
+	}
+	for _, t := range a.getConstraints().before {
+		if t.match(b) {
+			return true
+		}
+	}
+	for _, t := range b.getConstraints().after {
+		if t.match(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// topologicalSort order nodes by the constraints, nodes without constraints between them keep
+// their relative order, returns the cycle if the constraints can not be satisfied
+func topologicalSort(nodes []*Node) ([]*Node, []ISystem) {
+	n := len(nodes)
+	in := make([]int, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if precedes(nodes[i].val, nodes[j].val) {
+				in[j]++
+			}
+		}
+	}
+	sorted := make([]*Node, 0, n)
+	done := make([]bool, n)
+	for len(sorted) < n {
+		next := -1
+		for i := 0; i < n; i++ {
+			if !done[i] && in[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, findCycle(nodes, done)
+		}
+		done[next] = true
+		sorted = append(sorted, nodes[next])
+		for j := 0; j < n; j++ {
+			if !done[j] && precedes(nodes[next].val, nodes[j].val) {
+				in[j]--
+			}
+		}
+	}
+	return sorted, nil
+}
+
+// findCycle walk the remaining nodes backward along the constraints until a node repeats
+func findCycle(nodes []*Node, done []bool) []ISystem {
+	var path []int
+	visited := map[int]int{}
+	cur := -1
+	for i := range nodes {
+		if !done[i] {
+			cur = i
+			break
+		}
+	}
+	for cur >= 0 {
+		if at, ok := visited[cur]; ok {
+			cycle := make([]ISystem, 0, len(path)-at+1)
+			for i := len(path) - 1; i >= at; i-- {
+				cycle = append(cycle, nodes[path[i]].val)
+			}
+			return append(cycle, nodes[path[len(path)-1]].val)
+		}
+		visited[cur] = len(path)
+		path = append(path, cur)
+		prev := -1
+		for i := range nodes {
+			if !done[i] && precedes(nodes[i].val, nodes[cur].val) {
+				prev = i
+				break
+			}
+		}
+		cur = prev
+	}
+	return nil
+}
+
+func formatSystemCycle(cycle []ISystem) string {
+	names := make([]string, len(cycle))
+	for i, sys := range cycle {
+		names[i] = sys.Type().String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// checkConstraints report constraint cycles and constraints against the Order of the systems
+func (p *systemFlow) checkConstraints() error {
+	var errs []string
+	for _, period := range p.stageList {
+		var nodes []*Node
+		var groups []int
+		for i, sg := range p.stages[period] {
+			for _, node := range sg.systems {
+				nodes = append(nodes, node)
+				groups = append(groups, i)
+			}
+		}
+		if _, cycle := topologicalSort(nodes); cycle != nil {
+			errs = append(errs, fmt.Sprintf("%s, system order cycle: %s", period, formatSystemCycle(cycle)))
+		}
+		for i := range nodes {
+			for j := range nodes {
+				if groups[i] > groups[j] && precedes(nodes[i].val, nodes[j].val) {
+					errs = append(errs, fmt.Sprintf("%s, %s must run before %s but has a later Order",
+						period, nodes[i].val.Type().String(), nodes[j].val.Type().String()))
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package ecs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type __systemConstraint_Test_C_1 struct {
+	Component[__systemConstraint_Test_C_1]
+}
+
+type __systemConstraint_Test_S_A struct {
+	System[__systemConstraint_Test_S_A]
+}
+
+func (s *__systemConstraint_Test_S_A) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemConstraint_Test_C_1]{})
+	RunAfter[__systemConstraint_Test_S_B](si)
+	return nil
+}
+
+func (s *__systemConstraint_Test_S_A) Update(event Event) {}
+
+type __systemConstraint_Test_S_B struct {
+	System[__systemConstraint_Test_S_B]
+}
+
+func (s *__systemConstraint_Test_S_B) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemConstraint_Test_C_1]{})
+	RunAfterSet(si, "physics")
+	return nil
+}
+
+func (s *__systemConstraint_Test_S_B) Update(event Event) {}
+
+type __systemConstraint_Test_S_C struct {
+	System[__systemConstraint_Test_S_C]
+}
+
+func (s *__systemConstraint_Test_S_C) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemConstraint_Test_C_1]{})
+	InSet(si, "physics")
+	return nil
+}
+
+func (s *__systemConstraint_Test_S_C) Update(event Event) {}
+
+type __systemConstraint_Test_S_D struct {
+	System[__systemConstraint_Test_S_D]
+}
+
+func (s *__systemConstraint_Test_S_D) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemConstraint_Test_C_1]{})
+	RunBefore[__systemConstraint_Test_S_C](si)
+	RunAfter[__systemConstraint_Test_S_B](si)
+	return nil
+}
+
+func (s *__systemConstraint_Test_S_D) Update(event Event) {}
+
+func TestRunAfter(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__systemConstraint_Test_S_A](world)
+	RegisterSystem[__systemConstraint_Test_S_B](world)
+	RegisterSystem[__systemConstraint_Test_S_C](world)
+	world.Startup()
+
+	var batches []string
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		for ss := sg.Begin(); !sg.End(); ss = sg.Next() {
+			for _, sys := range ss {
+				batches = append(batches, sys.Type().Name())
+			}
+		}
+	}
+	want := []string{"__systemConstraint_Test_S_C", "__systemConstraint_Test_S_B", "__systemConstraint_Test_S_A"}
+	if fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Fatalf("batches, got %v", batches)
+	}
+}
+
+func TestRunBefore_cycle(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__systemConstraint_Test_S_B](world)
+	RegisterSystem[__systemConstraint_Test_S_C](world)
+	RegisterSystem[__systemConstraint_Test_S_D](world)
+	RegisterSystem[__systemConstraint_Test_S_A](world)
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Fatal("cycle not reported")
+		}
+		msg := fmt.Sprint(err)
+		for _, name := range []string{"_S_B", "_S_C", "_S_D"} {
+			if !strings.Contains(msg, name) {
+				t.Fatalf("cycle report, got %s", msg)
+			}
+		}
+		if strings.Contains(msg, "_S_A") {
+			t.Fatalf("system out of the cycle reported, got %s", msg)
+		}
+	}()
+	world.Startup()
+}
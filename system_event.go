@@ -5,6 +5,9 @@ import "time"
 type Event struct {
 	Frame uint64
 	Delta time.Duration
+	// Alpha interpolation factor in [0, 1) of the time left over after the fixed steps of this
+	// update, only set in fixed timestep mode
+	Alpha float64
 }
 
 type InitReceiver interface {
@@ -0,0 +1,231 @@
+package ecs
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// FailurePolicy how the world handles a panic in a system
+type FailurePolicy uint8
+
+const (
+	FailureDefault FailurePolicy = iota // the policy of WorldConfig.FailurePolicy, crash if not set
+	FailureCrash                        // panic on the main thread after the batch of the system
+	FailureSkip                         // skip the system in the rest of the frame
+	FailureDisable                      // mark the system broken, it never runs again
+	FailureRestart                      // skip the rest of the frame and re-run Init before the next frame
+)
+
+var failurePolicyNames = map[FailurePolicy]string{
+	FailureDefault: "Default",
+	FailureCrash:   "Crash",
+	FailureSkip:    "Skip",
+	FailureDisable: "Disable",
+	FailureRestart: "Restart",
+}
+
+func (p FailurePolicy) String() string {
+	if name, ok := failurePolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("FailurePolicy(%d)", p)
+}
+
+// WithFailurePolicy the failure policy of the system, overrides WorldConfig.FailurePolicy
+func WithFailurePolicy(policy FailurePolicy) SystemOption {
+	return systemOptionFunc(func(sys ISystem) {
+		sys.getFailure().policy = policy
+	})
+}
+
+// SystemFailure a panic in a system, or in a job of the world that is not a system (e.g. a temp
+// task of the flush), reported to WorldConfig.OnSystemFailure
+type SystemFailure struct {
+	Frame  uint64
+	System string // empty for a job that is not a system
+	Stage  string
+	Panic  any
+	Stack  []byte
+	Policy FailurePolicy // the policy applied
+}
+
+func (f *SystemFailure) Error() string {
+	if f.System == "" {
+		return fmt.Sprintf("job panicked in frame %d: %v\n%s", f.Frame, f.Panic, f.Stack)
+	}
+	return fmt.Sprintf("system %s panicked in %s of frame %d: %v\n%s", f.System, f.Stage, f.Frame, f.Panic, f.Stack)
+}
+
+// systemFailure the failure state of a system
+type systemFailure struct {
+	policy FailurePolicy
+	failed bool
+	frame  uint64
+}
+
+// skipped whether the system failed earlier in the frame
+func (f *systemFailure) skipped(frame uint64) bool {
+	return f.failed && f.frame == frame
+}
+
+// workerPanic a panic recovered on another goroutine, raised again in the system with its stack
+type workerPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *workerPanic) String() string {
+	return fmt.Sprintf("%v\n%s", p.value, p.stack)
+}
+
+// failureCollector the crash failures of a batch, raised on the main thread after the batch
+type failureCollector struct {
+	lock    sync.Mutex
+	crashed *SystemFailure
+}
+
+func (p *systemFlow) failurePolicy(sys ISystem) FailurePolicy {
+	policy := sys.getFailure().policy
+	if policy == FailureDefault {
+		policy = p.world.config.FailurePolicy
+	}
+	if policy == FailureDefault {
+		policy = FailureCrash
+	}
+	return policy
+}
+
+// recoverSystem report a panic in a system and apply its failure policy, deferred by invoke
+func (p *systemFlow) recoverSystem(sys ISystem, stage Stage, frame uint64) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	failure := &SystemFailure{
+		Frame:  frame,
+		System: sys.Type().String(),
+		Stage:  stage.String(),
+		Panic:  r,
+		Policy: p.failurePolicy(sys),
+	}
+	if wp, ok := r.(*workerPanic); ok {
+		failure.Panic = wp.value
+		failure.Stack = wp.stack
+	} else {
+		failure.Stack = debug.Stack()
+	}
+
+	state := sys.getFailure()
+	state.failed = true
+	state.frame = frame
+	switch failure.Policy {
+	case FailureCrash:
+		p.failures.lock.Lock()
+		if p.failures.crashed == nil {
+			p.failures.crashed = failure
+		}
+		p.failures.lock.Unlock()
+	case FailureDisable:
+		sys.setBroken()
+	case FailureRestart:
+		p.lock.Lock()
+		p.pending = append(p.pending, systemFlowOp{op: systemFlowOperateRestart, system: sys})
+		p.lock.Unlock()
+	}
+
+	if hook := p.world.config.OnSystemFailure; hook != nil {
+		hook(failure)
+	} else {
+		p.world.logger.forSystem(sys).Log(LogLevelError, "system panicked", "stage", failure.Stage,
+			"policy", failure.Policy, "panic", failure.Panic, "stack", string(failure.Stack))
+	}
+}
+
+// jobFailure report a panic in a job that is not a system to the hook or the logger of the world.
+// A job has no policy of its own, it crashes unless WorldConfig.FailurePolicy is another policy,
+// then the job is skipped and the world carries on
+func (p *systemFlow) jobFailure(r any, stack []byte, frame uint64) *SystemFailure {
+	policy := p.world.config.FailurePolicy
+	if policy == FailureDefault || policy == FailureCrash {
+		policy = FailureCrash
+	} else {
+		policy = FailureSkip
+	}
+	failure := &SystemFailure{Frame: frame, Panic: r, Stack: stack, Policy: policy}
+	if hook := p.world.config.OnSystemFailure; hook != nil {
+		hook(failure)
+	} else {
+		p.world.logger.Log(LogLevelError, "job panicked", "policy", failure.Policy, "panic", failure.Panic,
+			"stack", string(failure.Stack))
+	}
+	return failure
+}
+
+// recoverJob report a panic in a job of the flow before the job is done, a crash is raised on the
+// main thread after the jobs like the crash of a system
+func (p *systemFlow) recoverJob(frame uint64) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	failure := p.jobFailure(r, debug.Stack(), frame)
+	if failure.Policy == FailureCrash {
+		p.failures.lock.Lock()
+		if p.failures.crashed == nil {
+			p.failures.crashed = failure
+		}
+		p.failures.lock.Unlock()
+	}
+}
+
+// raiseCrash panic on the main thread with the first crash failure of the batch
+func (p *systemFlow) raiseCrash() {
+	p.failures.lock.Lock()
+	crashed := p.failures.crashed
+	p.failures.crashed = nil
+	p.failures.lock.Unlock()
+	if crashed != nil {
+		panic(crashed)
+	}
+}
+
+// restart remove the system and add it again, the destroy stages run first to release what the
+// system acquired, then Init runs again and the system starts over
+func (p *systemFlow) restart(sys ISystem) {
+	if _, ok := p.systems[sys.Type()]; !ok || sys.getState() >= SystemStateDestroy {
+		return
+	}
+	p.destroyNow(sys)
+	p.remove(sys)
+	sys.getFailure().failed = false
+	p.add(sys)
+}
+
+// destroyNow run the destroy stages of a system on the main thread, a panic is reported but does
+// not restart the system again
+func (p *systemFlow) destroyNow(sys ISystem) {
+	sys.setState(SystemStateDestroy)
+	event := Event{Frame: p.world.frame}
+	policy := sys.getFailure().policy
+	sys.getFailure().policy = FailureSkip
+	defer func() {
+		sys.getFailure().policy = policy
+	}()
+	run := func(stage Stage, fn func(event Event), sync bool) {
+		sys.setExecuting(true)
+		sys.setSecurity(sync)
+		p.invoke(sys, stage, []ISystem{sys}, fn, event)
+		sys.setSecurity(false)
+		sys.setExecuting(false)
+	}
+	if system, ok := sys.(SyncBeforeDestroyReceiver); ok {
+		run(StageSyncBeforeDestroy, system.SyncBeforeDestroy, true)
+	}
+	if system, ok := sys.(DestroyReceiver); ok {
+		run(StageDestroy, system.Destroy, false)
+	}
+	if system, ok := sys.(SyncAfterPostDestroyReceiver); ok {
+		run(StageSyncAfterDestroy, system.SyncAfterDestroy, true)
+	}
+}
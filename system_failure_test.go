@@ -0,0 +1,224 @@
+package ecs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type __systemFailure_Test_C_1 struct {
+	Component[__systemFailure_Test_C_1]
+	Field1 int
+}
+
+// __systemFailure_Test_S_1 panics in the update of frame 1, counts its inits, updates and destroys
+type __systemFailure_Test_S_1 struct {
+	System[__systemFailure_Test_S_1]
+
+	inits    int
+	updates  int
+	destroys int
+}
+
+func (s *__systemFailure_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemFailure_Test_C_1]{})
+	s.inits++
+	return nil
+}
+
+func (s *__systemFailure_Test_S_1) Update(event Event) {
+	s.updates++
+	if event.Frame == 1 {
+		panic("update failed")
+	}
+}
+
+func (s *__systemFailure_Test_S_1) PostUpdate(event Event) {
+	s.updates++
+}
+
+func (s *__systemFailure_Test_S_1) Destroy(event Event) {
+	s.destroys++
+}
+
+// __systemFailure_Test_S_2 panics in a helper of ParallelForEach
+type __systemFailure_Test_S_2 struct {
+	System[__systemFailure_Test_S_2]
+}
+
+func (s *__systemFailure_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__systemFailure_Test_C_1{})
+	return nil
+}
+
+func (s *__systemFailure_Test_S_2) Update(event Event) {
+	ParallelForEach(s, GetComponentAll[__systemFailure_Test_C_1](s), func(c *__systemFailure_Test_C_1) {
+		if c.Field1 == 5000 {
+			panic("element failed")
+		}
+	})
+}
+
+func newFailureTestWorld(t *testing.T, policy FailurePolicy) (*SyncWorld, *[]*SystemFailure) {
+	lock := &sync.Mutex{}
+	failures := &[]*SystemFailure{}
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.FailurePolicy = policy
+	config.OnSystemFailure = func(f *SystemFailure) {
+		lock.Lock()
+		*failures = append(*failures, f)
+		lock.Unlock()
+	}
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemFailure_Test_S_1](world)
+	world.Startup()
+	return world, failures
+}
+
+func getFailureTestSystem(world *SyncWorld) *__systemFailure_Test_S_1 {
+	s, _ := world.getSystem(TypeOf[__systemFailure_Test_S_1]())
+	return s.(*__systemFailure_Test_S_1)
+}
+
+func TestFailurePolicy(t *testing.T) {
+	tests := []struct {
+		policy   FailurePolicy
+		updates  int
+		inits    int
+		destroys int
+	}{
+		// frames 0, 1 and 2 run both stages, frame 1 stops after the panic in Update
+		{FailureSkip, 5, 1, 0},
+		{FailureDisable, 3, 1, 0},
+		{FailureRestart, 5, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			world, failures := newFailureTestWorld(t, tt.policy)
+			for i := 0; i < 3; i++ {
+				world.Update()
+			}
+			s := getFailureTestSystem(world)
+			if s.updates != tt.updates || s.inits != tt.inits || s.destroys != tt.destroys {
+				t.Fatalf("updates %d inits %d destroys %d", s.updates, s.inits, s.destroys)
+			}
+			if len(*failures) != 1 {
+				t.Fatalf("failures, got %d", len(*failures))
+			}
+			f := (*failures)[0]
+			if f.Frame != 1 || f.Stage != "StageUpdate" || f.Panic != "update failed" || f.Policy != tt.policy ||
+				f.System != TypeOf[__systemFailure_Test_S_1]().String() || len(f.Stack) == 0 {
+				t.Fatalf("failure, got %+v", f)
+			}
+		})
+	}
+}
+
+func TestFailurePolicy_crash(t *testing.T) {
+	world, failures := newFailureTestWorld(t, FailureDefault)
+	world.Update()
+	func() {
+		defer func() {
+			f, ok := recover().(*SystemFailure)
+			if !ok || f.Policy != FailureCrash {
+				t.Fatalf("crash on the main thread, got %v", f)
+			}
+		}()
+		world.Update()
+	}()
+	if len(*failures) != 1 {
+		t.Fatalf("failures, got %d", len(*failures))
+	}
+}
+
+func TestFailurePolicy_parallelForEach(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	var failure *SystemFailure
+	config.OnSystemFailure = func(f *SystemFailure) {
+		failure = f
+	}
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemFailure_Test_S_2](world, WithFailurePolicy(FailureDisable))
+	world.Startup()
+	for i := 0; i < 20000; i++ {
+		world.Add(world.NewEntity(), &__systemFailure_Test_C_1{Field1: i})
+	}
+	world.Update()
+	world.Update()
+	world.Update()
+	if failure == nil || failure.Panic != "element failed" || failure.Policy != FailureDisable {
+		t.Fatalf("failure, got %+v", failure)
+	}
+	s, _ := world.getSystem(TypeOf[__systemFailure_Test_S_2]())
+	if s.isValid() {
+		t.Fatal("system not disabled")
+	}
+}
+
+func TestFailurePolicy_job(t *testing.T) {
+	world, failures := newFailureTestWorld(t, FailureDefault)
+	world.Update()
+
+	// a job of the flow crashes on the main thread after the wait
+	flow := world.systemFlow
+	flow.addJob(world.frame, func() {
+		panic("job failed")
+	})
+	func() {
+		defer func() {
+			f, ok := recover().(*SystemFailure)
+			if !ok || f.Policy != FailureCrash || f.System != "" || f.Panic != "job failed" {
+				t.Fatalf("crash on the main thread, got %v", f)
+			}
+		}()
+		flow.wg.Wait()
+		flow.raiseCrash()
+	}()
+	if len(*failures) != 1 {
+		t.Fatalf("failures, got %d", len(*failures))
+	}
+}
+
+func TestFailurePolicy_poolJob(t *testing.T) {
+	reported := make(chan *SystemFailure, 1)
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.FailurePolicy = FailureSkip
+	config.OnSystemFailure = func(f *SystemFailure) {
+		reported <- f
+	}
+	config.MaxPoolThread = 1
+	world := NewSyncWorld(config)
+	world.Startup()
+
+	// any other job is reported, the worker restarts when the policy does not crash
+	world.addJob(func() {
+		panic("pool job failed")
+	})
+	if f := <-reported; f.Panic != "pool job failed" || f.Policy != FailureSkip || f.System != "" {
+		t.Fatalf("failure, got %+v", f)
+	}
+	ran := make(chan struct{})
+	world.addJob(func() {
+		close(ran)
+	})
+	<-ran
+}
+
+func TestTryAndReport(t *testing.T) {
+	debugTry := DebugTry
+	DebugTry = false
+	defer func() {
+		DebugTry = debugTry
+	}()
+	if err := TryAndReport(func() error { panic(42) }); err == nil || err.Error() != "42" {
+		t.Fatalf("panic, got %v", err)
+	}
+	if err := TryAndReport(func() error { return errTestTry }); err != errTestTry {
+		t.Fatalf("error, got %v", err)
+	}
+}
+
+var errTestTry = errors.New("try failed")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 )
 
 const (
@@ -31,6 +32,38 @@ const (
 // Stage system execute period:start->pre_update->update->pre_destroy->destroy
 type Stage uint32
 
+var stageNames = map[Stage]string{
+	StageSyncBeforeStart: "StageSyncBeforeStart",
+	StageStart:           "StageStart",
+	StageSyncAfterStart:  "StageSyncAfterStart",
+
+	StageSyncBeforePreUpdate: "StageSyncBeforePreUpdate",
+	StagePreUpdate:           "StagePreUpdate",
+	StageSyncAfterPreUpdate:  "StageSyncAfterPreUpdate",
+
+	StageSyncBeforeUpdate: "StageSyncBeforeUpdate",
+	StageUpdate:           "StageUpdate",
+	StageSyncAfterUpdate:  "StageSyncAfterUpdate",
+
+	StageSyncBeforePostUpdate: "StageSyncBeforePostUpdate",
+	StagePostUpdate:           "StagePostUpdate",
+	StageSyncAfterPostUpdate:  "StageSyncAfterPostUpdate",
+
+	StageSyncBeforeDestroy: "StageSyncBeforeDestroy",
+	StageDestroy:           "StageDestroy",
+	StageSyncAfterDestroy:  "StageSyncAfterDestroy",
+}
+
+func (s Stage) String() string {
+	if name, ok := stageNames[s]; ok {
+		return name
+	}
+	if desc, ok := getCustomStage(s); ok {
+		return desc.name
+	}
+	return fmt.Sprintf("Stage(%d)", uint32(s))
+}
+
 // Order default suborder of system
 type Order int32
 
@@ -44,13 +77,33 @@ const (
 // SystemGroupList extension of system group slice
 type SystemGroupList []*SystemGroup
 
+type systemFlowOperate uint8
+
+const (
+	systemFlowOperateRegister systemFlowOperate = iota
+	systemFlowOperateUnregister
+	systemFlowOperateRestart
+)
+
+type systemFlowOp struct {
+	op     systemFlowOperate
+	system ISystem
+	typ    reflect.Type
+}
+
 // system execute flow
 type systemFlow struct {
 	world     *ecsWorld
 	stages    map[Stage]SystemGroupList
 	stageList []Stage
 	systems   map[reflect.Type]ISystem
+	ordered   []ISystem // systems in registration order
 	wg        *sync.WaitGroup
+	lock      sync.Mutex
+	pending   []systemFlowOp // registrations while running, applied between frames
+	removing  []ISystem      // unregistered systems, removed after their destroy stages
+	slowest   slowestExecution
+	failures  failureCollector
 }
 
 func newSystemFlow(runtime *ecsWorld) *systemFlow {
@@ -101,18 +154,47 @@ func (p *systemFlow) reset() {
 	}
 }
 
-func (p *systemFlow) flushTempTask() {
-	tasks := p.world.components.getTempTasks()
-	p.wg.Add(len(tasks))
+func (p *systemFlow) flushTempTask(frame uint64) {
+	tracer, current := p.world.tracer, p.world.frame
+	start := time.Now()
+	p.playbackCommands()
+	p.world.hierarchy.flush(p.world)
+	p.world.relations.flush(p.world)
+	tasks := p.world.components.getTempTasks(frame)
 	for _, task := range tasks {
-		wg := p.wg
 		fn := task
-		p.world.addJob(func() {
+		p.addJob(current, func() {
+			taskStart := time.Now()
 			fn()
-			wg.Done()
+			tracer.span(current, "flush", "Temp Task", taskStart, nil)
 		})
 	}
 	p.wg.Wait()
+	p.raiseCrash()
+	p.world.archetypes.commit()
+	if tracer.tracing(current) {
+		tracer.span(current, "flush", "Temp Task Flush", start, map[string]any{"tasks": len(tasks)})
+	}
+}
+
+// addJob run a job of the flow that is not a system on the pool, waited with the systems
+func (p *systemFlow) addJob(frame uint64, job func()) {
+	wg := p.wg
+	wg.Add(1)
+	p.world.addJob(func() {
+		defer wg.Done()
+		defer p.recoverJob(frame)
+		job()
+	})
+}
+
+// playbackCommands apply the command buffers of the systems in registration order
+func (p *systemFlow) playbackCommands() {
+	for _, sys := range p.ordered {
+		if cb := sys.getCommandBuffer(); cb != nil && cb.Len() > 0 {
+			cb.playback(p.world)
+		}
+	}
 }
 
 func (p *systemFlow) systemUpdate(event Event) {
@@ -121,18 +203,29 @@ func (p *systemFlow) systemUpdate(event Event) {
 	var imp bool = false
 	var runSync bool = false
 	var fn func(event Event)
+	var sysEvent Event
+	p.tickSchedules(event)
+	tracer := p.world.tracer
 	for _, period := range p.stageList {
 		sq = p.stages[period]
+		stageStart := time.Now()
+		batchIndex := 0
 		for _, sl := range sq {
 			if sl.systemCount() == 0 {
 				continue
 			}
 			for ss := sl.Begin(); !sl.End(); ss = sl.Next() {
+				var check *accessCheck
+				if p.world.config.AccessCheck {
+					check = p.beginAccessCheck()
+				}
+				batchStart := time.Now()
+				batchRan := 0
 				if systemCount := len(ss); systemCount != 0 {
 					for i := 0; i < systemCount; i++ {
 						sys = ss[i]
 
-						if !sys.isValid() {
+						if !sys.isValid() || sys.getFailure().skipped(event.Frame) {
 							continue
 						}
 
@@ -148,7 +241,7 @@ func (p *systemFlow) systemUpdate(event Event) {
 						}
 
 						if state == SystemStateStart {
-							if period > StageSyncAfterStart {
+							if period > StageSyncAfterStart || isCustomStage(period) {
 								continue
 							}
 							switch period {
@@ -169,7 +262,10 @@ func (p *systemFlow) systemUpdate(event Event) {
 								runSync = true
 							}
 						} else if state == SystemStateUpdate {
-							if period < StageSyncBeforePreUpdate || period > StageSyncAfterPostUpdate {
+							if !isCustomStage(period) && (period < StageSyncBeforePreUpdate || period > StageSyncAfterPostUpdate) {
+								continue
+							}
+							if !sys.getSchedule().due {
 								continue
 							}
 							switch period {
@@ -220,9 +316,18 @@ func (p *systemFlow) systemUpdate(event Event) {
 								fn = system.SyncAfterPostUpdate
 								imp = ok
 								runSync = true
+							default:
+								system, ok := sys.(StageReceiver)
+								desc, _ := getCustomStage(period)
+								stage := period
+								fn = func(event Event) {
+									system.RunStage(stage, event)
+								}
+								imp = ok
+								runSync = desc.mode == StageModeSync
 							}
 						} else if state == SystemStateDestroy {
-							if period < StageSyncBeforeDestroy {
+							if period < StageSyncBeforeDestroy || isCustomStage(period) {
 								continue
 							}
 							switch period {
@@ -249,101 +354,271 @@ func (p *systemFlow) systemUpdate(event Event) {
 						if !imp {
 							continue
 						}
+						sys.markRun(event.Frame)
+						batchRan++
+						if check != nil {
+							check.systems = append(check.systems, sys)
+						}
+						sysEvent = event
+						if state == SystemStateUpdate {
+							sysEvent.Delta = sys.getSchedule().delta
+						}
 						if runSync {
 							sys.setExecuting(true)
 							sys.setSecurity(true)
-							fn(event)
+							p.invoke(sys, period, ss, fn, sysEvent)
 							sys.setSecurity(false)
 							sys.setExecuting(false)
 						} else {
-							wrapper := func(fn func(event2 Event), e Event) func() {
+							wrapper := func(sys ISystem, period Stage, batch []ISystem, fn func(event2 Event), e Event) func() {
 								sys.setExecuting(true)
 								return func() {
 									defer func() {
 										sys.setExecuting(false)
 										p.wg.Done()
 									}()
-									fn(e)
+									p.invoke(sys, period, batch, fn, e)
 								}
 							}
 							p.wg.Add(1)
-							p.world.addJob(wrapper(fn, event))
+							p.world.addJob(wrapper(sys, period, ss, fn, sysEvent))
 						}
 					}
 				}
 				p.wg.Wait()
+				p.raiseCrash()
+				if check != nil {
+					p.endAccessCheck(check, event.Frame)
+				}
+				if batchRan > 0 {
+					if tracer.tracing(event.Frame) {
+						tracer.span(event.Frame, "batch", "Batch", batchStart, map[string]any{"batch": batchIndex, "systems": batchRan})
+					}
+					batchIndex++
+				}
 			}
 		}
+		if batchIndex > 0 {
+			tracer.span(event.Frame, "stage", period.String(), stageStart, nil)
+		}
+	}
+}
+
+// invoke run a stage of the system in a batch, on the main thread or a worker
+func (p *systemFlow) invoke(sys ISystem, stage Stage, batch []ISystem, fn func(event Event), event Event) {
+	defer p.recoverSystem(sys, stage, event.Frame)
+	start := time.Now()
+	fn(event)
+	elapsed := time.Since(start)
+	if tracer := p.world.tracer; tracer.tracing(event.Frame) {
+		tracer.span(event.Frame, "system", sys.Type().String(), start, map[string]any{"stage": stage.String()})
+	}
+	sys.getTiming().record(stage, elapsed)
+	p.world.metrics.observeSystem(sys, stage, elapsed)
+	p.checkSystemBudget(sys, stage, batch, elapsed, event.Frame)
+}
+
+// tickSchedules decide which systems are due in this frame, paused systems do not accumulate time
+func (p *systemFlow) tickSchedules(event Event) {
+	for _, sys := range p.systems {
+		state := sys.getState()
+		if state == SystemStateStart || state == SystemStateUpdate {
+			sys.getSchedule().tick(event.Delta)
+		}
 	}
 }
 
 func (p *systemFlow) run(event Event) {
+	start := time.Now()
 	reporter := p.world.metrics.NewReporter("system_flow_run")
 	reporter.Start()
 
+	p.applyPending()
+
 	//Log.Info("system flow # Temp Task Execute #")
-	p.flushTempTask()
+	p.flushTempTask(event.Frame)
 	reporter.Sample("Temp Task Execute")
 
+	// modifications since the last detection are visible from this frame
+	p.world.components.detectChanges(event.Frame)
+	reporter.Sample("Detect Changes")
+
+	// events of the frame before last are dropped
+	p.world.events.swap()
+
 	//Log.Info("system flow # Logic #")
 	p.systemUpdate(event)
 	reporter.Sample("system execute")
 
 	//Log.Info("system flow # Clear Disposable #")
+	disposableStart := time.Now()
 	p.world.components.clearDisposable()
+	p.world.tracer.span(event.Frame, "flush", "Clear Disposable", disposableStart, nil)
 	reporter.Sample("Clear Disposable")
 
-	p.flushTempTask()
-	reporter.Sample("Temp Task Execute")
+	p.flushTempTask(event.Frame + 1)
+	reporter.Sample("Temp Task Execute Next Frame")
+
+	p.removeStopped()
 
 	reporter.Stop()
-	reporter.Print()
+	p.world.metrics.printFrame(reporter)
+	p.world.metrics.observeFrame(reporter)
+	p.world.metrics.collectWorld(p.world)
+	p.checkFrameBudget(time.Since(start), event.Frame)
 }
 
-// register method only in world init or func init(){}
+// register add the system in world init, or queue it to the next frame while running
 func (p *systemFlow) register(system ISystem) {
-	if p.world.getStatus() != WorldStatusInitialized {
-		panic("system register only in world init")
+	switch p.world.getStatus() {
+	case WorldStatusInitialized:
+		p.add(system)
+	case WorldStatusRunning:
+		p.lock.Lock()
+		p.pending = append(p.pending, systemFlowOp{op: systemFlowOperateRegister, system: system})
+		p.lock.Unlock()
+	default:
+		panic("system register only in world init or running")
 	}
+}
+
+// unregister remove the system in world init, or queue it to the next frame while running, the
+// system runs its destroy stages in that frame and is removed at the end of it
+func (p *systemFlow) unregister(typ reflect.Type) {
+	switch p.world.getStatus() {
+	case WorldStatusInitialized:
+		if sys, ok := p.systems[typ]; ok {
+			p.remove(sys)
+		}
+	case WorldStatusRunning:
+		p.lock.Lock()
+		p.pending = append(p.pending, systemFlowOp{op: systemFlowOperateUnregister, typ: typ})
+		p.lock.Unlock()
+	default:
+		panic("system unregister only in world init or running")
+	}
+}
+
+// applyPending apply the queued registrations at the safe point before the frame, a system of the
+// same type as a system being removed waits for the removal, so that the system can be hot swapped
+func (p *systemFlow) applyPending() {
+	p.lock.Lock()
+	ops := p.pending
+	p.pending = nil
+	p.lock.Unlock()
+
+	var retry []systemFlowOp
+	for _, op := range ops {
+		switch op.op {
+		case systemFlowOperateRegister:
+			typ := op.system.Type()
+			if sys, ok := p.systems[typ]; ok {
+				if sys.getState() >= SystemStateDestroy {
+					retry = append(retry, op)
+				} else {
+					p.world.logger.Log(LogLevelError, "repeated system", "system", typ.String())
+				}
+				continue
+			}
+			p.add(op.system)
+		case systemFlowOperateUnregister:
+			sys, ok := p.systems[op.typ]
+			if !ok || sys.getState() >= SystemStateDestroy {
+				continue
+			}
+			sys.stop()
+			p.removing = append(p.removing, sys)
+		case systemFlowOperateRestart:
+			p.restart(op.system)
+		}
+	}
+
+	if len(ops) > len(retry) {
+		if err := p.checkConstraints(); err != nil {
+			p.world.logger.Log(LogLevelError, err.Error())
+		}
+	}
+
+	if len(retry) > 0 {
+		p.lock.Lock()
+		p.pending = append(retry, p.pending...)
+		p.lock.Unlock()
+	}
+}
+
+func (p *systemFlow) removeStopped() {
+	for _, sys := range p.removing {
+		p.remove(sys)
+	}
+	p.removing = p.removing[:0]
+}
 
+func (p *systemFlow) remove(system ISystem) {
+	for _, period := range p.stageList {
+		for _, sg := range p.stages[period] {
+			if sg.has(system) {
+				sg.remove(system)
+			}
+		}
+	}
+	for i, sys := range p.ordered {
+		if sys == system {
+			p.ordered = append(p.ordered[:i], p.ordered[i+1:]...)
+			break
+		}
+	}
+	delete(p.systems, system.Type())
+	if u := system.GetUtility(); u != nil {
+		delete(p.world.utilities, u.Type())
+	}
+	system.setState(SystemStateDestroyed)
+}
+
+func (p *systemFlow) add(system ISystem) {
 	//init function call
 	system.baseInit(p.world, system)
 
 	order := system.Order()
 	if order > OrderAppend {
-		Log.Errorf("system order must less then %d, resort order to %d", OrderAppend+1, OrderAppend)
+		p.world.logger.forSystem(system).Errorf("system order must less then %d, resort order to %d", OrderAppend+1, OrderAppend)
 		order = OrderAppend
 	}
 
 	for _, period := range p.stageList {
-
 		if !p.isImpEvent(system, period) {
 			continue
 		}
-
-		sl := p.stages[period]
-		if order == OrderFront {
-			p.stages[period][0].insert(system)
-		} else if order == OrderAppend {
-			p.stages[period][len(sl)-1].insert(system)
-		} else {
-			for i, v := range sl {
-				if order == v.order {
-					v.insert(system)
-					break
-				} else if order < v.order {
-					sg := NewSystemGroup()
-					sg.order = order
-					sg.insert(system)
-					temp := append(SystemGroupList{}, sl[i-1:]...)
-					p.stages[period] = append(append(sl[:i-1], sg), temp...)
-					break
-				}
-			}
-		}
+		p.insertToStage(system, period, order)
 	}
 
 	p.systems[system.Type()] = system
+	p.ordered = append(p.ordered, system)
+}
+
+// insertToStage insert the system to the group of its order in the stage
+func (p *systemFlow) insertToStage(system ISystem, period Stage, order Order) {
+	sl := p.stages[period]
+	if order == OrderFront {
+		sl[0].insert(system)
+		return
+	}
+	if order == OrderAppend {
+		sl[len(sl)-1].insert(system)
+		return
+	}
+	for i, v := range sl {
+		if order == v.order {
+			v.insert(system)
+			return
+		} else if order < v.order {
+			sg := NewSystemGroup()
+			sg.order = order
+			sg.insert(system)
+			temp := append(SystemGroupList{}, sl[i:]...)
+			p.stages[period] = append(append(sl[:i], sg), temp...)
+			return
+		}
+	}
 }
 
 func (p *systemFlow) isImpEvent(system ISystem, period Stage) bool {
@@ -379,6 +654,10 @@ func (p *systemFlow) isImpEvent(system ISystem, period Stage) bool {
 		_, imp = system.(DestroyReceiver)
 	case StageSyncAfterDestroy:
 		_, imp = system.(SyncAfterPostDestroyReceiver)
+	default:
+		if _, ok := system.(StageReceiver); ok {
+			imp = system.isInStage(period)
+		}
 	}
 	return imp
 }
@@ -388,29 +667,9 @@ func (p *systemFlow) stop() {
 }
 
 func (p *systemFlow) SystemInfoPrint() {
-	m := map[Stage]string{
-		StageSyncBeforeStart: "StageSyncBeforeStart",
-		StageStart:           "StageStart",
-		StageSyncAfterStart:  "StageSyncAfterStart",
-
-		StageSyncBeforePreUpdate: "StageSyncBeforePreUpdate",
-		StagePreUpdate:           "StagePreUpdate",
-		StageSyncAfterPreUpdate:  "StageSyncAfterPreUpdate",
-
-		StageSyncBeforeUpdate: "StageSyncBeforeUpdate",
-		StageUpdate:           "StageUpdate",
-		StageSyncAfterUpdate:  "StageSyncAfterUpdate",
-
-		StageSyncBeforePostUpdate: "StageSyncBeforePostUpdate",
-		StagePostUpdate:           "StagePostUpdate",
-		StageSyncAfterPostUpdate:  "StageSyncAfterPostUpdate",
-
-		StageSyncBeforeDestroy: "StageSyncBeforeDestroy",
-		StageDestroy:           "StageDestroy",
-		StageSyncAfterDestroy:  "StageSyncAfterDestroy",
-	}
-	Log.Infof("┌──────────────── # System Info # ─────────────────")
-	Log.Infof("├─ Total: %d", len(p.systems))
+	logger := p.world.logger
+	logger.Infof("┌──────────────── # System Info # ─────────────────")
+	logger.Infof("├─ Total: %d", len(p.systems))
 
 	var output []string
 	var sq SystemGroupList
@@ -455,9 +714,9 @@ func (p *systemFlow) SystemInfoPrint() {
 		if len(slContent) > 0 {
 			s := make([]string, 0, len(slContent)+1)
 			if pi == len(p.stageList)-1 {
-				s = append(s, fmt.Sprintf("└─ Stage %s", m[period]))
+				s = append(s, fmt.Sprintf("└─ Stage %s", period))
 			} else {
-				s = append(s, fmt.Sprintf("├─ Stage %s", m[period]))
+				s = append(s, fmt.Sprintf("├─ Stage %s", period))
 			}
 			s = append(s, slContent...)
 			output = append(output, s...)
@@ -465,7 +724,7 @@ func (p *systemFlow) SystemInfoPrint() {
 	}
 
 	for _, v := range output {
-		Log.Info(v)
+		logger.Log(LogLevelInfo, v)
 	}
-	Log.Infof("└────────────── # System Info End # ───────────────")
+	logger.Infof("└────────────── # System Info End # ───────────────")
 }
@@ -0,0 +1,142 @@
+package ecs
+
+import (
+	"testing"
+)
+
+type __systemFlow_Test_C_1 struct {
+	Component[__systemFlow_Test_C_1]
+	Field1 int
+}
+
+type __systemFlow_Test_S_1 struct {
+	System[__systemFlow_Test_S_1]
+
+	calls []string
+}
+
+func (s *__systemFlow_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__systemFlow_Test_C_1{})
+	return nil
+}
+
+func (s *__systemFlow_Test_S_1) Start(event Event) {
+	s.calls = append(s.calls, "start")
+}
+
+func (s *__systemFlow_Test_S_1) Update(event Event) {
+	s.calls = append(s.calls, "update")
+}
+
+func (s *__systemFlow_Test_S_1) Destroy(event Event) {
+	s.calls = append(s.calls, "destroy")
+}
+
+type __systemFlow_Test_S_2 struct {
+	System[__systemFlow_Test_S_2]
+}
+
+func (s *__systemFlow_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemFlow_Test_C_1]{})
+	return nil
+}
+
+func (s *__systemFlow_Test_S_2) Update(event Event) {}
+
+func __systemFlow_Test_calls(world *SyncWorld) (*__systemFlow_Test_S_1, string) {
+	s, ok := world.getSystem(TypeOf[__systemFlow_Test_S_1]())
+	if !ok {
+		return nil, ""
+	}
+	sys := s.(*__systemFlow_Test_S_1)
+	calls := ""
+	for _, c := range sys.calls {
+		calls += c + " "
+	}
+	return sys, calls
+}
+
+func TestUnregisterSystem(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__systemFlow_Test_S_2](world)
+	world.Startup()
+	world.Update()
+
+	// registered while running, starts in the next frame
+	RegisterSystem[__systemFlow_Test_S_1](world)
+	if _, ok := world.getSystem(TypeOf[__systemFlow_Test_S_1]()); ok {
+		t.Fatal("registration while running must be deferred")
+	}
+	world.Update()
+	old, calls := __systemFlow_Test_calls(world)
+	if calls != "start update " {
+		t.Fatalf("lifecycle of registered system, got %s", calls)
+	}
+
+	// hot swap, the old instance is destroyed and removed, then the new one starts
+	UnregisterSystem[__systemFlow_Test_S_1](world)
+	RegisterSystem[__systemFlow_Test_S_1](world)
+	world.Update()
+	if calls := old.calls[len(old.calls)-1]; calls != "destroy" {
+		t.Fatalf("lifecycle of unregistered system, got %v", old.calls)
+	}
+	if _, ok := world.getSystem(TypeOf[__systemFlow_Test_S_1]()); ok {
+		t.Fatal("unregistered system not removed")
+	}
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		if sg.has(old) {
+			t.Fatal("unregistered system still in system group")
+		}
+	}
+	world.Update()
+	sys, calls := __systemFlow_Test_calls(world)
+	if sys == nil || sys == old || calls != "start update " {
+		t.Fatalf("hot swapped system, got %s", calls)
+	}
+	if len(world.systemFlow.ordered) != 2 {
+		t.Fatalf("systems, got %d", len(world.systemFlow.ordered))
+	}
+}
+
+type __systemFlow_Test_S_3 struct {
+	System[__systemFlow_Test_S_3]
+
+	updates int
+}
+
+func (s *__systemFlow_Test_S_3) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemFlow_Test_C_1]{})
+	return nil
+}
+
+func (s *__systemFlow_Test_S_3) Update(event Event) {
+	s.updates++
+}
+
+func TestSystemFlow_insertToStage(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__systemFlow_Test_S_1](world, Order(20))
+	RegisterSystem[__systemFlow_Test_S_2](world, Order(10))
+	// lands between the groups of order 10 and 20
+	RegisterSystem[__systemFlow_Test_S_3](world, Order(15))
+	world.Startup()
+	world.Update()
+
+	var orders []Order
+	for _, sg := range world.systemFlow.stages[StageUpdate] {
+		orders = append(orders, sg.order)
+	}
+	want := []Order{OrderFront, 10, 15, 20, OrderAppend}
+	if len(orders) != len(want) {
+		t.Fatalf("group orders, got %v", orders)
+	}
+	for i := range want {
+		if orders[i] != want[i] {
+			t.Fatalf("group orders, got %v", orders)
+		}
+	}
+	s, _ := world.getSystem(TypeOf[__systemFlow_Test_S_3]())
+	if updates := s.(*__systemFlow_Test_S_3).updates; updates != 1 {
+		t.Fatalf("system in the middle of the stage updated %d times", updates)
+	}
+}
@@ -12,38 +12,36 @@ type Node struct {
 	parent   *Node
 	children []*Node
 	val      ISystem
+	level    int
 }
 
 func (p *Node) isFriend(node *Node) bool {
+	return len(p.conflicts(node)) > 0
+}
+
+// conflicts the component and event types written by one of the systems and accessed by the other
+func (p *Node) conflicts(node *Node) []reflect.Type {
+	var types []reflect.Type
 	for com, r := range p.val.GetRequirements() {
 		for comTarget, rTarget := range node.val.GetRequirements() {
 			if comTarget == com {
 				if r.getPermission() == ComponentReadOnly && rTarget.getPermission() == ComponentReadOnly {
 					continue
 				}
-				return true
+				types = append(types, com)
 			}
 		}
 	}
-	return false
-}
-
-func (p *Node) attach(node *Node) {
-	isAttached := false
-	for i := 0; i < len(p.children); i++ {
-		if p.children[i].isFriend(node) {
-			p.children[i].attach(node)
-			isAttached = true
-			break
-		}
-	}
-	if !isAttached {
-		if p.val == node.val {
-			Log.Error("repeated system")
-			return
+	// an event writer and its readers conflict like components
+	for typ, permission := range p.val.getEventAccess() {
+		if pTarget, ok := node.val.getEventAccess()[typ]; ok {
+			if permission == ComponentReadOnly && pTarget == ComponentReadOnly {
+				continue
+			}
+			types = append(types, typ)
 		}
-		p.children = append(p.children, node)
 	}
+	return types
 }
 
 // SystemGroup system group ordered by interrelation
@@ -59,7 +57,7 @@ type SystemGroup struct {
 }
 
 func NewSystemGroup() *SystemGroup {
-	return &SystemGroup{
+	sg := &SystemGroup{
 		systems: make([]*Node, 0),
 		ref:     map[reflect.Type]int{},
 		ordered: true,
@@ -69,6 +67,9 @@ func NewSystemGroup() *SystemGroup {
 			val:      nil,
 		},
 	}
+	// iterable before the first resort
+	sg.group = sg
+	return sg
 }
 
 func (p *SystemGroup) refCount(rqs map[reflect.Type]IRequirement) int {
@@ -87,11 +88,26 @@ func (p *SystemGroup) resort() {
 		return p.refCount(p.systems[i].val.GetRequirements()) >
 			p.refCount(p.systems[j].val.GetRequirements())
 	})
+	if sorted, cycle := topologicalSort(p.systems); cycle != nil {
+		cycle[0].World().base().logger.Errorf("system order cycle, constraints ignored: %s", formatSystemCycle(cycle))
+	} else {
+		p.systems = sorted
+	}
 
+	// a node runs in the batch after the latest earlier node it conflicts with or must follow
 	p.root.children = []*Node{}
-	for _, node := range p.systems {
+	for i, node := range p.systems {
 		node.children = []*Node{}
-		p.root.attach(node)
+		node.level = 0
+		parent := p.root
+		for _, prev := range p.systems[:i] {
+			if prev.level+1 > node.level && (prev.isFriend(node) || precedes(prev.val, node.val)) {
+				node.level = prev.level + 1
+				parent = prev
+			}
+		}
+		node.parent = parent
+		parent.children = append(parent.children, node)
 	}
 	p.ordered = true
 
@@ -0,0 +1,84 @@
+package ecs
+
+import "time"
+
+// SystemOption option of system registration, an Order is an option as well
+type SystemOption interface {
+	applyTo(sys ISystem)
+}
+
+func (o Order) applyTo(sys ISystem) {
+	sys.setOrder(o)
+}
+
+type systemOptionFunc func(sys ISystem)
+
+func (f systemOptionFunc) applyTo(sys ISystem) {
+	f(sys)
+}
+
+// WithInterval run the update periods of the system once per interval, the change filters and
+// event readers of the system see everything since its last run, e.g.
+//
+//	ecs.RegisterSystem[MoveSystem](world, ecs.WithInterval(50*time.Millisecond))
+func WithInterval(interval time.Duration) SystemOption {
+	return systemOptionFunc(func(sys ISystem) {
+		sys.getSchedule().interval = interval
+	})
+}
+
+// WithFrameDivisor run the update periods of the system once every n frames, the change filters
+// and event readers of the system see everything since its last run
+func WithFrameDivisor(n uint64) SystemOption {
+	return systemOptionFunc(func(sys ISystem) {
+		sys.getSchedule().divisor = n
+	})
+}
+
+// systemSchedule tick rate of a system, start and destroy periods are not affected
+type systemSchedule struct {
+	interval time.Duration
+	divisor  uint64
+
+	started bool
+	acc     time.Duration
+	frames  uint64
+	since   time.Duration
+	due     bool
+	delta   time.Duration
+}
+
+// tick accumulate the frame time and decide whether the system is due in this frame, a system
+// always runs in its first update frame, the remainder of the interval is kept to avoid drift
+func (s *systemSchedule) tick(delta time.Duration) {
+	s.since += delta
+	s.frames++
+	if s.interval > 0 {
+		s.acc += delta
+	}
+
+	s.due = true
+	if s.started {
+		if s.interval > 0 && s.acc < s.interval {
+			s.due = false
+		}
+		if s.divisor > 1 && s.frames < s.divisor {
+			s.due = false
+		}
+	}
+	if !s.due {
+		return
+	}
+
+	if s.interval > 0 {
+		if s.started {
+			s.acc %= s.interval
+		} else {
+			s.acc = 0
+		}
+	}
+	s.started = true
+	s.delta = s.since
+	s.since = 0
+	s.frames = 0
+}
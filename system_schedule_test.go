@@ -0,0 +1,196 @@
+package ecs
+
+import (
+	"testing"
+	"time"
+)
+
+type __systemSchedule_Test_C_1 struct {
+	Component[__systemSchedule_Test_C_1]
+	Field1 int
+}
+
+type __systemSchedule_Test_S_1 struct {
+	System[__systemSchedule_Test_S_1]
+
+	frames []uint64
+}
+
+func (s *__systemSchedule_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__systemSchedule_Test_C_1{})
+	return nil
+}
+
+func (s *__systemSchedule_Test_S_1) Update(event Event) {
+	s.frames = append(s.frames, event.Frame)
+}
+
+type __systemSchedule_Test_S_2 struct {
+	System[__systemSchedule_Test_S_2]
+
+	count int
+}
+
+func (s *__systemSchedule_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemSchedule_Test_C_1]{})
+	return nil
+}
+
+func (s *__systemSchedule_Test_S_2) Update(event Event) {
+	s.count++
+}
+
+func TestWithFrameDivisor(t *testing.T) {
+	world := NewSyncWorld(NewDefaultWorldConfig())
+	RegisterSystem[__systemSchedule_Test_S_1](world, WithFrameDivisor(3))
+	RegisterSystem[__systemSchedule_Test_S_2](world, OrderFront)
+	world.Startup()
+
+	for i := 0; i < 7; i++ {
+		world.Update()
+	}
+
+	s1, _ := world.getSystem(TypeOf[__systemSchedule_Test_S_1]())
+	frames := s1.(*__systemSchedule_Test_S_1).frames
+	if len(frames) != 3 || frames[1]-frames[0] != 3 || frames[2]-frames[1] != 3 {
+		t.Fatalf("divided system frames, got %v", frames)
+	}
+	s2, _ := world.getSystem(TypeOf[__systemSchedule_Test_S_2]())
+	if count := s2.(*__systemSchedule_Test_S_2).count; count != 7 {
+		t.Fatalf("default system runs every frame, got %d", count)
+	}
+}
+
+func Test_systemSchedule_tick(t *testing.T) {
+	s := systemSchedule{interval: 50 * time.Millisecond}
+	frame := 20 * time.Millisecond
+
+	var deltas []time.Duration
+	for i := 0; i < 8; i++ {
+		s.tick(frame)
+		if s.due {
+			deltas = append(deltas, s.delta)
+		}
+	}
+	// runs in the first frame, then the remainder of the interval is kept between runs
+	want := []time.Duration{20 * time.Millisecond, 60 * time.Millisecond, 40 * time.Millisecond}
+	if len(deltas) != len(want) {
+		t.Fatalf("due count, got %v", deltas)
+	}
+	for i := range want {
+		if deltas[i] != want[i] {
+			t.Fatalf("delta %d, want %v got %v", i, want[i], deltas[i])
+		}
+	}
+}
+
+type __systemSchedule_Test_C_2 struct {
+	Component[__systemSchedule_Test_C_2]
+	Field1 int
+}
+
+type __systemSchedule_Test_Shape_1 struct {
+	c2 *__systemSchedule_Test_C_2 `ecs:"added"`
+}
+
+type __systemSchedule_Test_E_1 struct {
+	Frame uint64
+}
+
+type __systemSchedule_Test_S_Writer struct {
+	System[__systemSchedule_Test_S_Writer]
+
+	writer *EventWriter[__systemSchedule_Test_E_1]
+}
+
+func (s *__systemSchedule_Test_S_Writer) Init(si SystemInitConstraint) error {
+	s.writer = NewEventWriter[__systemSchedule_Test_E_1](si)
+	return nil
+}
+
+func (s *__systemSchedule_Test_S_Writer) Update(event Event) {
+	s.writer.Send(__systemSchedule_Test_E_1{Frame: event.Frame})
+}
+
+// __systemSchedule_Test_S_Reader reads added components and events at a lower tick rate
+type __systemSchedule_Test_S_Reader struct {
+	System[__systemSchedule_Test_S_Reader]
+
+	shape  *Shape[__systemSchedule_Test_Shape_1]
+	reader *EventReader[__systemSchedule_Test_E_1]
+	frames []uint64
+	added  []int
+	events []uint64
+}
+
+func (s *__systemSchedule_Test_S_Reader) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &Added[__systemSchedule_Test_C_2]{})
+	s.shape = NewShape[__systemSchedule_Test_Shape_1](si)
+	s.reader = NewEventReader[__systemSchedule_Test_E_1](si)
+	return nil
+}
+
+func (s *__systemSchedule_Test_S_Reader) Update(event Event) {
+	s.frames = append(s.frames, event.Frame)
+	iter := s.shape.Get()
+	for v := iter.Begin(); !iter.End(); v = iter.Next() {
+		s.added = append(s.added, v.c2.Field1)
+	}
+	for _, e := range s.reader.Read() {
+		s.events = append(s.events, e.Frame)
+	}
+}
+
+func TestSystemSchedule_filtersAndEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		option SystemOption
+	}{
+		{"divisor", WithFrameDivisor(3)},
+		{"interval", WithInterval(60 * time.Millisecond)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewDefaultWorldConfig()
+			config.MetaInfoDebugPrint = false
+			world := NewSyncWorld(config)
+			RegisterSystem[__systemSchedule_Test_S_Writer](world)
+			RegisterSystem[__systemSchedule_Test_S_Reader](world, tt.option)
+			world.Startup()
+			update := func() {
+				// 20ms frames, the interval system runs every third frame like the divided one
+				world.delta = 20 * time.Millisecond
+				world.Update()
+			}
+
+			// frame 0 runs the reader, the component is added in frame 1 and modified in frame 2
+			update()
+			e := world.NewEntity()
+			world.Add(e, &__systemSchedule_Test_C_2{Field1: 1})
+			update()
+			set := world.getComponentSet(TypeOf[__systemSchedule_Test_C_2]()).(*ComponentSet[__systemSchedule_Test_C_2])
+			set.Get(e).Field1 = 2
+			for i := 0; i < 5; i++ {
+				update()
+			}
+
+			s, _ := world.getSystem(TypeOf[__systemSchedule_Test_S_Reader]())
+			sys := s.(*__systemSchedule_Test_S_Reader)
+			if len(sys.frames) != 3 || sys.frames[1] != 3 || sys.frames[2] != 6 {
+				t.Fatalf("reader frames, got %v", sys.frames)
+			}
+			if len(sys.added) != 1 || sys.added[0] != 2 {
+				t.Fatalf("added and modified before the reader ran, got %v", sys.added)
+			}
+			// the events of the frames the reader skipped are kept for it
+			if len(sys.events) != 7 {
+				t.Fatalf("events, got %v", sys.events)
+			}
+			for i, frame := range sys.events {
+				if frame != uint64(i) {
+					t.Fatalf("events missed or read twice, got %v", sys.events)
+				}
+			}
+		})
+	}
+}
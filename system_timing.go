@@ -0,0 +1,212 @@
+package ecs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// samples kept per system and stage for the percentiles
+const systemTimingWindow = 128
+
+// WithBudget the budget of a single execution of the system in a stage, overrides
+// WorldConfig.SystemBudget
+func WithBudget(budget time.Duration) SystemOption {
+	return systemOptionFunc(func(sys ISystem) {
+		sys.getTiming().budget = budget
+	})
+}
+
+// BudgetExceeded a frame or a system execution over its budget
+type BudgetExceeded struct {
+	Frame   uint64
+	IsFrame bool          // the whole frame is over budget, the system is the slowest execution in the frame
+	Elapsed time.Duration // of the frame or the system execution
+	Budget  time.Duration
+	System  string
+	Stage   string
+	Batch   []string // the systems of the batch the system ran in
+	// SystemElapsed execution time of the system, equal to Elapsed unless IsFrame
+	SystemElapsed time.Duration
+}
+
+// SystemTiming the execution time of a system in a stage, over the last executions
+type SystemTiming struct {
+	System string
+	Stage  string
+	Count  uint64 // executions since registration
+	Last   time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+type timingWindow struct {
+	samples [systemTimingWindow]time.Duration
+	count   uint64
+}
+
+// systemTiming the rolling execution times of a system, written by the worker running the system
+// and read by any goroutine
+type systemTiming struct {
+	lock       sync.Mutex
+	budget     time.Duration
+	windows    map[Stage]*timingWindow
+	histograms map[Stage]*metricHistogram // metrics of the system, created by Metrics
+}
+
+func (t *systemTiming) record(stage Stage, elapsed time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.windows == nil {
+		t.windows = map[Stage]*timingWindow{}
+	}
+	w, ok := t.windows[stage]
+	if !ok {
+		w = &timingWindow{}
+		t.windows[stage] = w
+	}
+	w.samples[w.count%systemTimingWindow] = elapsed
+	w.count++
+}
+
+// histogram the metric histogram of a stage, created on the first execution in the stage
+func (t *systemTiming) histogram(stage Stage, create func() *metricHistogram) *metricHistogram {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	h, ok := t.histograms[stage]
+	if !ok {
+		if t.histograms == nil {
+			t.histograms = map[Stage]*metricHistogram{}
+		}
+		h = create()
+		t.histograms[stage] = h
+	}
+	return h
+}
+
+func (t *systemTiming) stats(sys ISystem) []SystemTiming {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var stats []SystemTiming
+	for stage, w := range t.windows {
+		n := w.count
+		if n > systemTimingWindow {
+			n = systemTimingWindow
+		}
+		samples := append([]time.Duration(nil), w.samples[:n]...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		percentile := func(p int) time.Duration {
+			return samples[(len(samples)-1)*p/100]
+		}
+		stats = append(stats, SystemTiming{
+			System: sys.Type().String(),
+			Stage:  stage.String(),
+			Count:  w.count,
+			Last:   w.samples[(w.count-1)%systemTimingWindow],
+			P50:    percentile(50),
+			P90:    percentile(90),
+			P99:    percentile(99),
+			Max:    samples[len(samples)-1],
+		})
+	}
+	return stats
+}
+
+// SystemTimings the execution times of the systems, ordered by registration and stage. Must be
+// called on the main thread like ExportSchedule, for a running AsyncWorld use it in Sync/Wait.
+func (w *ecsWorld) SystemTimings() []SystemTiming {
+	w.checkMainThread()
+	var timings []SystemTiming
+	for _, sys := range w.systemFlow.ordered {
+		stats := sys.getTiming().stats(sys)
+		sort.Slice(stats, func(i, j int) bool { return stats[i].Stage < stats[j].Stage })
+		timings = append(timings, stats...)
+	}
+	return timings
+}
+
+// slowestExecution the slowest system execution of a frame
+type slowestExecution struct {
+	lock    sync.Mutex
+	sys     ISystem
+	stage   Stage
+	batch   []ISystem
+	elapsed time.Duration
+}
+
+func (s *slowestExecution) observe(sys ISystem, stage Stage, batch []ISystem, elapsed time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.sys != nil && elapsed <= s.elapsed {
+		return
+	}
+	s.sys = sys
+	s.stage = stage
+	s.batch = append(s.batch[:0], batch...)
+	s.elapsed = elapsed
+}
+
+func (s *slowestExecution) reset() {
+	s.sys = nil
+	s.batch = s.batch[:0]
+	s.elapsed = 0
+}
+
+func batchNames(batch []ISystem) []string {
+	names := make([]string, len(batch))
+	for i, sys := range batch {
+		names[i] = sys.Type().String()
+	}
+	return names
+}
+
+// checkSystemBudget called after each execution of a system
+func (p *systemFlow) checkSystemBudget(sys ISystem, stage Stage, batch []ISystem, elapsed time.Duration, frame uint64) {
+	config := p.world.config
+	if config.OnBudgetExceeded == nil {
+		return
+	}
+	p.slowest.observe(sys, stage, batch, elapsed)
+	budget := sys.getTiming().budget
+	if budget == 0 {
+		budget = config.SystemBudget
+	}
+	if budget == 0 || elapsed <= budget {
+		return
+	}
+	config.OnBudgetExceeded(BudgetExceeded{
+		Frame:         frame,
+		Elapsed:       elapsed,
+		Budget:        budget,
+		System:        sys.Type().String(),
+		Stage:         stage.String(),
+		Batch:         batchNames(batch),
+		SystemElapsed: elapsed,
+	})
+}
+
+// checkFrameBudget called at the end of a frame, the budget defaults to the frame interval
+func (p *systemFlow) checkFrameBudget(elapsed time.Duration, frame uint64) {
+	config := p.world.config
+	if config.OnBudgetExceeded == nil {
+		return
+	}
+	defer p.slowest.reset()
+	budget := config.FrameBudget
+	if budget == 0 {
+		budget = config.FrameInterval
+	}
+	if budget == 0 || elapsed <= budget {
+		return
+	}
+	e := BudgetExceeded{Frame: frame, IsFrame: true, Elapsed: elapsed, Budget: budget}
+	if p.slowest.sys != nil {
+		e.System = p.slowest.sys.Type().String()
+		e.Stage = p.slowest.stage.String()
+		e.Batch = batchNames(p.slowest.batch)
+		e.SystemElapsed = p.slowest.elapsed
+	}
+	config.OnBudgetExceeded(e)
+}
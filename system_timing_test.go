@@ -0,0 +1,94 @@
+package ecs
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type __systemTiming_Test_C_1 struct {
+	Component[__systemTiming_Test_C_1]
+}
+
+type __systemTiming_Test_S_1 struct {
+	System[__systemTiming_Test_S_1]
+}
+
+func (s *__systemTiming_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__systemTiming_Test_C_1{})
+	return nil
+}
+
+func (s *__systemTiming_Test_S_1) Update(event Event) {
+	time.Sleep(2 * time.Millisecond)
+}
+
+type __systemTiming_Test_S_2 struct {
+	System[__systemTiming_Test_S_2]
+}
+
+func (s *__systemTiming_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &ReadOnly[__systemTiming_Test_C_1]{})
+	return nil
+}
+
+func (s *__systemTiming_Test_S_2) Update(event Event) {}
+
+func TestBudgetExceeded(t *testing.T) {
+	lock := &sync.Mutex{}
+	var exceeded []BudgetExceeded
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.FrameBudget = time.Millisecond
+	config.OnBudgetExceeded = func(e BudgetExceeded) {
+		lock.Lock()
+		exceeded = append(exceeded, e)
+		lock.Unlock()
+	}
+	world := NewSyncWorld(config)
+	RegisterSystem[__systemTiming_Test_S_1](world, WithBudget(time.Millisecond))
+	RegisterSystem[__systemTiming_Test_S_2](world)
+	world.Startup()
+
+	world.Update()
+	world.Update()
+
+	var systems, frames int
+	for _, e := range exceeded {
+		if e.System != TypeOf[__systemTiming_Test_S_1]().String() || e.Stage != "StageUpdate" {
+			t.Fatalf("slow system, got %+v", e)
+		}
+		if e.IsFrame {
+			frames++
+			continue
+		}
+		if e.Elapsed != e.SystemElapsed || len(e.Batch) != 1 {
+			t.Fatalf("system over budget, got %+v", e)
+		}
+		systems++
+	}
+	if systems != 2 || frames != 2 {
+		t.Fatalf("budget callbacks, got %+v", exceeded)
+	}
+
+	timings := world.SystemTimings()
+	if len(timings) != 2 || timings[0].Count != 2 || timings[0].P50 < 2*time.Millisecond || timings[0].Max < timings[0].P50 {
+		t.Fatalf("timings, got %+v", timings)
+	}
+}
+
+func Test_systemTiming_stats(t *testing.T) {
+	timing := &systemTiming{}
+	for i := 1; i <= 300; i++ {
+		timing.record(StageUpdate, time.Duration(i))
+	}
+	stats := timing.stats(&__systemTiming_Test_S_2{})
+	if len(stats) != 1 {
+		t.Fatalf("stages, got %+v", stats)
+	}
+	// the window keeps the last 128 samples, 173..300
+	s := stats[0]
+	if s.Count != 300 || s.Last != 300 || s.Max != 300 || s.P50 != 236 || s.P99 != 298 {
+		t.Fatalf("stats, got %+v", s)
+	}
+}
@@ -0,0 +1,137 @@
+package ecs
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Tracer record the timeline of a frame range in the Chrome trace_event format, open the output in
+// chrome://tracing or Perfetto. Spans on the main thread are the frames, stages, batches, temp
+// task flushes and clearDisposable, systems and temp tasks are on the goroutine they ran on
+type Tracer struct {
+	from uint64
+	to   uint64
+
+	lock    sync.Mutex
+	pid     int64
+	main    int64
+	origin  time.Time
+	events  []traceEvent
+	threads map[int64]bool
+	done    chan struct{}
+	closed  bool
+}
+
+type traceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`
+	Dur  float64        `json:"dur,omitempty"`
+	Pid  int64          `json:"pid"`
+	Tid  int64          `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// NewTracer trace the frames from `from` to `to` inclusive, set it to WorldConfig.Tracer, a tracer
+// is used by one world
+func NewTracer(from uint64, to uint64) *Tracer {
+	return &Tracer{
+		from:    from,
+		to:      to,
+		origin:  time.Now(),
+		threads: map[int64]bool{},
+		done:    make(chan struct{}),
+	}
+}
+
+func (t *Tracer) attach(world *ecsWorld) {
+	t.pid = world.id
+}
+
+// tracing whether the frame is in the range, a nil tracer traces nothing
+func (t *Tracer) tracing(frame uint64) bool {
+	return t != nil && frame >= t.from && frame <= t.to
+}
+
+// span record a span ended now, on the calling goroutine
+func (t *Tracer) span(frame uint64, cat string, name string, start time.Time, args map[string]any) {
+	if !t.tracing(frame) {
+		return
+	}
+	end := time.Now()
+	tid := goroutineID()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return
+	}
+	t.threads[tid] = true
+	t.events = append(t.events, traceEvent{
+		Name: name,
+		Cat:  cat,
+		Ph:   "X",
+		Ts:   float64(start.Sub(t.origin).Nanoseconds()) / 1e3,
+		Dur:  float64(end.Sub(start).Nanoseconds()) / 1e3,
+		Pid:  t.pid,
+		Tid:  tid,
+		Args: args,
+	})
+}
+
+// endFrame called on the main thread at the end of a frame
+func (t *Tracer) endFrame(frame uint64, start time.Time) {
+	if !t.tracing(frame) {
+		return
+	}
+	t.lock.Lock()
+	t.main = goroutineID()
+	t.lock.Unlock()
+	t.span(frame, "frame", "Frame", start, map[string]any{"frame": frame})
+	if frame == t.to {
+		t.lock.Lock()
+		t.closed = true
+		close(t.done)
+		t.lock.Unlock()
+	}
+}
+
+// Done closed after the last frame of the range
+func (t *Tracer) Done() <-chan struct{} {
+	return t.done
+}
+
+// WriteJSON write the spans recorded in the Chrome trace_event JSON format, the main thread is
+// named "main" and the others "worker"
+func (t *Tracer) WriteJSON(w io.Writer) error {
+	t.lock.Lock()
+	events := make([]traceEvent, 0, len(t.events)+len(t.threads)+1)
+	events = append(events, traceEvent{
+		Name: "process_name",
+		Ph:   "M",
+		Pid:  t.pid,
+		Args: map[string]any{"name": "world"},
+	})
+	for tid := range t.threads {
+		name := "worker"
+		if tid == t.main {
+			name = "main"
+		}
+		events = append(events, traceEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			Pid:  t.pid,
+			Tid:  tid,
+			Args: map[string]any{"name": name},
+		})
+	}
+	events = append(events, t.events...)
+	t.lock.Unlock()
+
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
@@ -0,0 +1,90 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type __tracer_Test_C_1 struct {
+	Component[__tracer_Test_C_1]
+}
+
+type __tracer_Test_C_2 struct {
+	Component[__tracer_Test_C_2]
+}
+
+type __tracer_Test_S_1 struct {
+	System[__tracer_Test_S_1]
+}
+
+func (s *__tracer_Test_S_1) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__tracer_Test_C_1{})
+	return nil
+}
+
+func (s *__tracer_Test_S_1) Update(event Event) {}
+
+type __tracer_Test_S_2 struct {
+	System[__tracer_Test_S_2]
+}
+
+func (s *__tracer_Test_S_2) Init(si SystemInitConstraint) error {
+	s.SetRequirements(si, &__tracer_Test_C_2{})
+	return nil
+}
+
+func (s *__tracer_Test_S_2) Update(event Event) {}
+
+func TestTracer(t *testing.T) {
+	tracer := NewTracer(1, 2)
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.Tracer = tracer
+	world := NewSyncWorld(config)
+	RegisterSystem[__tracer_Test_S_1](world)
+	RegisterSystem[__tracer_Test_S_2](world)
+	world.Startup()
+
+	for i := 0; i < 4; i++ {
+		world.Update()
+	}
+	select {
+	case <-tracer.Done():
+	default:
+		t.Fatal("tracer not done after the last frame")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tracer.WriteJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	trace := struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}{}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	var main int64
+	for _, e := range trace.TraceEvents {
+		if e.Ph == "M" {
+			if e.Name == "thread_name" && e.Args["name"] == "main" {
+				main = e.Tid
+			}
+			continue
+		}
+		counts[e.Name]++
+	}
+	// the systems run in the same batch of the two traced frames
+	if counts["Frame"] != 2 || counts["StageUpdate"] != 2 || counts["Batch"] != 2 || counts["Clear Disposable"] != 2 ||
+		counts[TypeOf[__tracer_Test_S_1]().String()] != 2 || counts[TypeOf[__tracer_Test_S_2]().String()] != 2 {
+		t.Fatalf("spans, got %v", counts)
+	}
+	for _, e := range trace.TraceEvents {
+		if e.Name == "Frame" && e.Tid != main {
+			t.Fatal("frame span not on the main thread")
+		}
+	}
+}
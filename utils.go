@@ -2,6 +2,7 @@ package ecs
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"reflect"
 	"runtime/debug"
@@ -73,12 +74,11 @@ func TryAndReport(task func() error) (err error) {
 			case string:
 				err = errors.New(r.(string))
 			default:
-				_ = typ
+				err = fmt.Errorf("%v", typ)
 			}
 		}
 	}()
-	err = task()
-	return nil
+	return task()
 }
 
 func IsPureValueType(typ reflect.Type) bool {
@@ -20,7 +20,11 @@ func (w *SyncWorld) Update() {
 	w.update()
 }
 
-func (w *SyncWorld) Optimize(t time.Duration, force bool) {}
+// Optimize reorder component sets by shape usage in the idle time t, force ignores the time budget
+func (w *SyncWorld) Optimize(t time.Duration, force bool) {
+	w.checkMainThread()
+	w.optimize(t, force)
+}
 
 func (w *SyncWorld) Stop() {
 	w.stop()
@@ -54,6 +58,22 @@ func (w *SyncWorld) Remove(entity Entity, components ...IComponent) {
 	info.Remove(w, components...)
 }
 
+func (w *SyncWorld) SetParent(child Entity, parent Entity) {
+	info, ok := w.getEntityInfo(child)
+	if !ok {
+		return
+	}
+	info.SetParent(w, parent)
+}
+
+func (w *SyncWorld) RemoveParent(child Entity) {
+	info, ok := w.getEntityInfo(child)
+	if !ok {
+		return
+	}
+	info.RemoveParent(w)
+}
+
 func (w *SyncWorld) getWorld() IWorld {
 	return w
 }
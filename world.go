@@ -18,18 +18,34 @@ const (
 )
 
 type WorldConfig struct {
-	Debug              bool //Debug模式
-	MetaInfoDebugPrint bool
-	MainThreadCheck    bool
-	IsMetrics          bool
-	IsMetricsPrint     bool
-	CpuNum             int    //使用的最大cpu数量
-	MaxPoolThread      uint32 //线程池最大线程数量
-	MaxPoolJobQueue    uint32 //线程池最大任务队列长度
-	HashCount          int    //容器桶数量
-	CollectionVersion  int
-	FrameInterval      time.Duration //帧间隔
-	StopCallback       func(world *ecsWorld)
+	Debug                bool //Debug模式
+	MetaInfoDebugPrint   bool
+	MainThreadCheck      bool
+	IsMetrics            bool   //采集阶段耗时、系统耗时等指标，通过MetricsHandler导出
+	IsMetricsPrint       bool   //按MetricsPrintInterval间隔在日志中输出最近一帧的阶段耗时
+	CpuNum               int    //使用的最大cpu数量
+	MaxPoolThread        uint32 //线程池最大线程数量
+	MaxPoolJobQueue      uint32 //线程池最大任务队列长度
+	HashCount            int    //容器桶数量
+	CollectionVersion    int
+	FrameInterval        time.Duration //帧间隔
+	StopCallback         func(world *ecsWorld)
+	StableComponentID    bool                    //组件类型ID由类型名哈希生成，跨进程一致
+	ComponentManifest    *ComponentManifest      //启动时校验组件类型ID与内存布局
+	ArchetypeStorage     bool                    //按组件组合(archetype)连续存储，Shape按archetype遍历
+	FixedTimestep        bool                    //固定步长模式，累积真实时间，按FrameInterval执行固定步长的update
+	MaxCatchUp           int                     //固定步长模式下单次update最多追赶的步数，超出的时间被丢弃
+	AccessCheck          bool                    //访问检查，批次前后校验组件数据，报告修改只读组件或访问未声明组件的系统，开销较大
+	OnAccessViolation    func(v AccessViolation) //访问违规回调，可能在工作线程调用，为空时输出错误日志
+	FrameBudget          time.Duration           //帧预算，为0时使用FrameInterval
+	SystemBudget         time.Duration           //系统单次执行的预算，为0时不检查，可用WithBudget为单个系统设置
+	OnBudgetExceeded     func(e BudgetExceeded)  //帧或系统执行超出预算时的回调，系统超出时可能在工作线程调用，为空时不检查预算
+	Tracer               *Tracer                 //时间线追踪，按Chrome trace格式记录指定帧范围内的阶段和系统执行
+	Logger               FieldLogger             //World日志，自动附加world、frame和system字段，为空时使用全局Log
+	FailurePolicy        FailurePolicy           //系统panic时的默认处理策略，可用WithFailurePolicy为单个系统设置，默认FailureCrash
+	OnSystemFailure      func(f *SystemFailure)  //系统panic的回调，可能在工作线程调用，为空时输出错误日志
+	IdleOptimize         time.Duration           //异步World帧间空闲时间不少于该值时执行内存整理，为0时不执行
+	MetricsPrintInterval time.Duration           //IsMetricsPrint时输出阶段耗时的间隔，为0时每秒输出一次
 }
 
 func NewDefaultWorldConfig() *WorldConfig {
@@ -52,9 +68,11 @@ type IWorld interface {
 	getID() int64
 	addFreeComponent(component IComponent)
 	registerSystem(system ISystem)
+	unregisterSystem(typ reflect.Type)
 	registerComponent(component IComponent)
 	getMetrics() *Metrics
 	getEntityInfo(id Entity) (*EntityInfo, bool)
+	IsAlive(entity Entity) bool
 	newEntity() *EntityInfo
 	deleteEntity(entity Entity)
 	getComponentMetaInfoByType(typ reflect.Type) *ComponentMetaInfo
@@ -86,6 +104,11 @@ type ecsWorld struct {
 	optimizer       *optimizer
 	idGenerator     *EntityIDGenerator
 	componentMeta   *componentMeta
+	replicator      *replicator
+	archetypes      *archetypeStorage
+	hierarchy       *hierarchy
+	relations       *relations
+	events          *eventQueues
 	utilities       map[reflect.Type]IUtility
 	workPool        *Pool
 	metrics         *Metrics
@@ -93,14 +116,22 @@ type ecsWorld struct {
 	ts              time.Time
 	delta           time.Duration
 	pureUpdateDelta time.Duration
+	fixedTs         time.Time
+	accumulator     time.Duration
 	mainThreadID    int64
+	tracer          *Tracer
+	logger          *worldLogger
 }
 
 func (w *ecsWorld) init(config *WorldConfig) *ecsWorld {
 	w.id = LocalUniqueID()
+	w.logger = newWorldLogger(w, config.Logger)
 	w.systemFlow = nil
 	w.config = config
 	w.entities = NewEntityCollection()
+	w.hierarchy = newHierarchy()
+	w.relations = newRelations()
+	w.events = newEventQueues()
 	w.ts = time.Now()
 
 	if w.config.MaxPoolThread <= 0 {
@@ -119,20 +150,38 @@ func (w *ecsWorld) init(config *WorldConfig) *ecsWorld {
 	w.utilities = make(map[reflect.Type]IUtility)
 
 	w.metrics = NewMetrics(w.config.IsMetrics, w.config.IsMetricsPrint)
+	w.metrics.world = w.id
+	w.metrics.logger = w.logger
+	w.metrics.printInterval = w.config.MetricsPrintInterval
+	w.tracer = config.Tracer
+	if w.tracer != nil {
+		w.tracer.attach(w)
+	}
 
 	w.components = NewComponentCollection(w, config.HashCount)
 	w.optimizer = newOptimizer(w)
+	if w.config.ArchetypeStorage {
+		w.archetypes = newArchetypeStorage(w)
+	}
 
 	if w.config.FrameInterval <= 0 {
 		w.config.FrameInterval = time.Millisecond * 33
 	}
 
+	if w.config.FixedTimestep && w.config.MaxCatchUp <= 0 {
+		w.config.MaxCatchUp = 5
+	}
+
 	if w.config.HashCount == 0 {
 		w.config.HashCount = config.CpuNum
 	}
 
 	sf := newSystemFlow(w)
 	w.systemFlow = sf
+	// panics of jobs that are not systems are reported like the failures of the systems
+	w.workPool.onPanic = func(r any, stack []byte) bool {
+		return sf.jobFailure(r, stack, w.frame).Policy == FailureCrash
+	}
 
 	w.setStatus(WorldStatusInitialized)
 
@@ -156,6 +205,16 @@ func (w *ecsWorld) startup() {
 		panic("world is not initialized or already running.")
 	}
 
+	if w.config.ComponentManifest != nil {
+		if err := w.config.ComponentManifest.Check(w); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := w.systemFlow.checkConstraints(); err != nil {
+		panic(err)
+	}
+
 	if w.config.MetaInfoDebugPrint || w.config.Debug {
 		w.systemFlow.SystemInfoPrint()
 		w.componentMeta.ComponentMetaInfoPrint()
@@ -163,6 +222,7 @@ func (w *ecsWorld) startup() {
 
 	w.SwitchMainThread()
 	w.workPool.Start()
+	w.fixedTs = time.Now()
 	w.setStatus(WorldStatusRunning)
 }
 
@@ -174,9 +234,37 @@ func (w *ecsWorld) update() {
 	if w.status != WorldStatusRunning {
 		panic("world is not running, must startup first.")
 	}
-	e := Event{Delta: w.delta, Frame: w.frame}
+	if w.config.FixedTimestep {
+		now := time.Now()
+		elapsed := now.Sub(w.fixedTs)
+		w.fixedTs = now
+		w.fixedUpdate(elapsed)
+		return
+	}
+	w.runFrame(Event{Delta: w.delta, Frame: w.frame})
+}
+
+// fixedUpdate accumulate the elapsed real time and run as many fixed steps of FrameInterval as
+// needed, at most MaxCatchUp, whole steps beyond the cap are dropped so a long stall can not spiral
+func (w *ecsWorld) fixedUpdate(elapsed time.Duration) {
+	step := w.config.FrameInterval
+	w.accumulator += elapsed
+	steps := int(w.accumulator / step)
+	if steps > w.config.MaxCatchUp {
+		steps = w.config.MaxCatchUp
+		w.accumulator = step*time.Duration(steps) + w.accumulator%step
+	}
+	w.accumulator -= step * time.Duration(steps)
+	alpha := float64(w.accumulator) / float64(step)
+	for i := 0; i < steps; i++ {
+		w.runFrame(Event{Delta: step, Frame: w.frame, Alpha: alpha})
+	}
+}
+
+func (w *ecsWorld) runFrame(e Event) {
 	start := time.Now()
 	w.systemFlow.run(e)
+	w.tracer.endFrame(e.Frame, start)
 	now := time.Now()
 	w.delta = now.Sub(w.ts)
 	w.pureUpdateDelta = now.Sub(start)
@@ -220,10 +308,19 @@ func (w *ecsWorld) getMetrics() *Metrics {
 }
 
 func (w *ecsWorld) registerSystem(system ISystem) {
-	w.checkMainThread()
+	if w.getStatus() == WorldStatusInitialized {
+		w.checkMainThread()
+	}
 	w.systemFlow.register(system)
 }
 
+func (w *ecsWorld) unregisterSystem(typ reflect.Type) {
+	if w.getStatus() == WorldStatusInitialized {
+		w.checkMainThread()
+	}
+	w.systemFlow.unregister(typ)
+}
+
 func (w *ecsWorld) registerComponent(component IComponent) {
 	w.checkMainThread()
 	w.componentMeta.GetOrCreateComponentMetaInfo(component)
@@ -249,8 +346,24 @@ func (w *ecsWorld) getEntityInfo(entity Entity) (*EntityInfo, bool) {
 	return w.entities.GetEntityInfo(entity)
 }
 
+// IsAlive false for destroyed entities and stale handles whose index has been reused
+func (w *ecsWorld) IsAlive(entity Entity) bool {
+	return w.entities.Exist(entity)
+}
+
 func (w *ecsWorld) deleteEntity(entity Entity) {
-	w.entities.Remove(entity)
+	if w.replicator != nil {
+		if info, ok := w.entities.GetEntityInfo(entity); ok {
+			w.replicator.onDestroy(info)
+		}
+	}
+	w.archetypes.touch(entity)
+	if w.entities.Remove(entity) == nil {
+		return
+	}
+	w.idGenerator.FreeID(entity)
+	w.hierarchy.operate(hierarchyOperateDestroy, entity, 0)
+	w.relations.operate(relationOperateDestroy, nil, entity, 0)
 }
 
 func (w *ecsWorld) getComponentSet(typ reflect.Type) IComponentSet {
@@ -302,7 +415,7 @@ func (w *ecsWorld) addFreeComponent(component IComponent) {
 	switch component.getComponentType() {
 	case ComponentTypeFree, ComponentTypeFreeDisposable:
 	default:
-		Log.Errorf("component not free type, %s", component.Type().String())
+		w.logger.Errorf("component not free type, %s", component.Type().String())
 		return
 	}
 	w.addComponent(0, component)
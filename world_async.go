@@ -17,6 +17,10 @@ func (g SyncWrapper) NewEntity() Entity {
 	return g.getWorld().newEntity().Entity()
 }
 
+func (g SyncWrapper) IsAlive(entity Entity) bool {
+	return (*g.world).IsAlive(entity)
+}
+
 func (g SyncWrapper) DestroyEntity(entity Entity) {
 	info, ok := (*g.world).getEntityInfo(entity)
 	if !ok {
@@ -41,6 +45,22 @@ func (g SyncWrapper) Remove(entity Entity, components ...IComponent) {
 	info.Remove(*g.world, components...)
 }
 
+func (g SyncWrapper) SetParent(child Entity, parent Entity) {
+	info, ok := (*g.world).getEntityInfo(child)
+	if !ok {
+		return
+	}
+	info.SetParent(*g.world, parent)
+}
+
+func (g SyncWrapper) RemoveParent(child Entity) {
+	info, ok := (*g.world).getEntityInfo(child)
+	if !ok {
+		return
+	}
+	info.RemoveParent(*g.world)
+}
+
 type syncTask struct {
 	wait chan struct{}
 	fn   func(wrapper SyncWrapper) error
@@ -69,7 +89,7 @@ func (w *AsyncWorld) Startup() {
 
 		frameInterval := w.config.FrameInterval
 		w.setStatus(WorldStatusRunning)
-		Log.Info("start world success")
+		w.logger.Log(LogLevelInfo, "start world success")
 
 		for {
 			select {
@@ -88,13 +108,31 @@ func (w *AsyncWorld) Startup() {
 			}
 			w.update()
 			//world.Info(delta, frameInterval - delta)
-			if d := frameInterval - w.delta; d > 0 {
-				time.Sleep(d)
+			if w.config.FixedTimestep {
+				// idle until the next fixed step is due
+				w.idle(frameInterval - w.accumulator)
+			} else {
+				w.idle(frameInterval - w.delta)
 			}
 		}
 	}()
 }
 
+// idle optimize the world in the idle time between two frames and sleep the rest
+func (w *AsyncWorld) idle(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if w.config.IdleOptimize > 0 && d >= w.config.IdleOptimize {
+		start := time.Now()
+		w.optimize(d, false)
+		d -= time.Since(start)
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
 func (w *AsyncWorld) Stop() {
 	w.wStop <- struct{}{}
 }
@@ -111,7 +149,7 @@ func (w *AsyncWorld) dispatch() {
 			return task.fn(gaw)
 		})
 		if err != nil {
-			Log.Error(err)
+			w.logger.Log(LogLevelError, "sync task failed", "error", err)
 		}
 		if task.wait != nil {
 			task.wait <- struct{}{}
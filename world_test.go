@@ -165,3 +165,112 @@ func Test_ecsWorld_World_launcher(t *testing.T) {
 	wg.Wait()
 	world.Stop()
 }
+
+func Test_ecsWorld_IsAlive(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	world := NewSyncWorld(config)
+	world.registerComponent(&__world_Test_C_1{})
+	world.registerComponent(&__world_Test_C_2{})
+	world.Startup()
+
+	var entities []Entity
+	for i := 0; i < 20; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__world_Test_C_1{Field1: i})
+		entities = append(entities, e)
+	}
+	world.Update()
+
+	// enough frees to flush the delayed ids, the indices are reused
+	for _, e := range entities[:10] {
+		world.DestroyEntity(e)
+	}
+	world.Update()
+	stale := entities[0]
+	if world.IsAlive(stale) {
+		t.Fatal("destroyed entity alive")
+	}
+
+	reused := false
+	for i := 0; i < 10; i++ {
+		e := world.NewEntity()
+		world.Add(e, &__world_Test_C_1{Field1: 100 + i})
+		if e.ToRealID().index == stale.ToRealID().index {
+			reused = true
+			if e == stale || !world.IsAlive(e) {
+				t.Fatal("reused entity has the same generation")
+			}
+		}
+	}
+	if !reused {
+		t.Fatal("entity index not reused")
+	}
+	world.Update()
+
+	set := world.getComponentSet(TypeOf[__world_Test_C_1]())
+	if set.getPointerByEntity(stale) != nil {
+		t.Fatal("stale entity resolves to a component")
+	}
+	world.Add(stale, &__world_Test_C_2{})
+	world.Remove(stale, &__world_Test_C_1{})
+	world.Update()
+	set2 := world.components.getCollections().Get(GetComponentMeta[__world_Test_C_2](world).it)
+	if set.Len() != 20 || set2 != nil && (*set2).Len() != 0 {
+		t.Fatal("operation on stale entity applied")
+	}
+}
+
+type __world_Test_S_Fixed struct {
+	System[__world_Test_S_Fixed]
+
+	events []Event
+}
+
+func (w *__world_Test_S_Fixed) Init(si SystemInitConstraint) error {
+	w.SetRequirements(si, &ReadOnly[__world_Test_C_1]{})
+	return nil
+}
+
+func (w *__world_Test_S_Fixed) Update(event Event) {
+	w.events = append(w.events, event)
+}
+
+func Test_ecsWorld_fixedUpdate(t *testing.T) {
+	config := NewDefaultWorldConfig()
+	config.MetaInfoDebugPrint = false
+	config.FixedTimestep = true
+	config.FrameInterval = 10 * time.Millisecond
+	config.MaxCatchUp = 3
+	world := NewSyncWorld(config)
+	RegisterSystem[__world_Test_S_Fixed](world)
+	world.Startup()
+	s, _ := world.getSystem(TypeOf[__world_Test_S_Fixed]())
+	sys := s.(*__world_Test_S_Fixed)
+
+	world.fixedUpdate(25 * time.Millisecond)
+	if len(sys.events) != 2 {
+		t.Fatalf("steps, got %d", len(sys.events))
+	}
+	for _, e := range sys.events {
+		if e.Delta != config.FrameInterval || e.Alpha != 0.5 {
+			t.Fatalf("event of fixed step, got %+v", e)
+		}
+	}
+
+	// the remainder is carried over
+	world.fixedUpdate(5 * time.Millisecond)
+	if len(sys.events) != 3 || sys.events[2].Alpha != 0 {
+		t.Fatalf("carried step, got %+v", sys.events)
+	}
+
+	// catch up is capped, the dropped steps are not replayed later
+	world.fixedUpdate(100*time.Millisecond + 2*time.Millisecond)
+	if len(sys.events) != 6 {
+		t.Fatalf("capped steps, got %d", len(sys.events))
+	}
+	world.fixedUpdate(0)
+	if len(sys.events) != 6 || world.accumulator != 2*time.Millisecond {
+		t.Fatalf("dropped steps replayed, got %d", len(sys.events))
+	}
+}